@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bookmark marks a page, or a heading or line within it. Positions are kept
+// relative to a section heading so they survive the page being rendered at
+// another width.
+type Bookmark struct {
+	Name    string    `json:"name"`
+	Section string    `json:"section"`
+	Heading string    `json:"heading,omitempty"` // section heading, e.g. "OPTIONS"
+	Line    int       `json:"line,omitempty"`    // lines below Heading, or below the top of the page
+	Tags    []string  `json:"tags,omitempty"`
+	Added   time.Time `json:"added,omitzero"`
+}
+
+// Page returns the man page the bookmark points into
+func (b Bookmark) Page() ManPage {
+	return ManPage{Name: b.Name, Section: b.Section}
+}
+
+// String describes the bookmark, e.g. "tar(1) › OPTIONS +12"
+func (b Bookmark) String() string {
+	s := fmt.Sprintf("%s(%s)", b.Name, b.Section)
+	if b.Heading != "" {
+		s += " › " + b.Heading
+	}
+	if b.Line > 0 {
+		s += fmt.Sprintf(" +%d", b.Line)
+	}
+	return s
+}
+
+// sameTarget reports whether b and other point at the same place
+func (b Bookmark) sameTarget(other Bookmark) bool {
+	return b.Name == other.Name && b.Section == other.Section &&
+		b.Heading == other.Heading && b.Line == other.Line
+}
+
+// HasTag reports whether b is in the collection tag
+func (b Bookmark) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// bookmarkFile is the layout of bookmarks.json and of exported reading lists
+type bookmarkFile struct {
+	Version   int        `json:"version"`
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// Bookmarks is the user's bookmark list, saved to the data directory after
+// every change
+type Bookmarks struct {
+	path  string
+	items []Bookmark
+	pages map[string]bool // "name(section)" of every bookmarked page
+}
+
+// LoadBookmarks reads the bookmark file, an empty list if there's none
+func LoadBookmarks() (*Bookmarks, error) {
+	b := &Bookmarks{pages: make(map[string]bool)}
+	dir, err := dataDir()
+	if err != nil {
+		return b, fmt.Errorf("no data directory for bookmarks: %w", err)
+	}
+
+	path := filepath.Join(dir, "bookmarks.json")
+	items, err := readBookmarkFile(path)
+	if err != nil {
+		return b, err
+	}
+	b.path = path
+	b.items = items
+	b.reindex()
+	return b, nil
+}
+
+// readBookmarkFile reads the bookmarks at path, none if there's no file
+func readBookmarkFile(path string) ([]Bookmark, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks: %w", err)
+	}
+	var file bookmarkFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid bookmarks file %s: %w", path, err)
+	}
+	return file.Bookmarks, nil
+}
+
+func (b *Bookmarks) reindex() {
+	b.pages = make(map[string]bool, len(b.items))
+	for _, item := range b.items {
+		b.pages[fmt.Sprintf("%s(%s)", item.Name, item.Section)] = true
+	}
+}
+
+// update applies change to the list as saved and saves it, holding a lock
+// meanwhile, so that another lazyman's changes since the list was loaded are
+// kept rather than overwritten
+func (b *Bookmarks) update(change func()) error {
+	defer b.reindex()
+	if b.path == "" {
+		change()
+		return errors.New("bookmarks can't be saved: no usable bookmarks file")
+	}
+	unlock, err := lockFile(b.path+".lock", dataLockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	defer unlock()
+	items, err := readBookmarkFile(b.path)
+	if err != nil {
+		return err
+	}
+	b.items = items
+	change()
+
+	data, err := json.MarshalIndent(bookmarkFile{Version: 1, Bookmarks: b.items}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	if err := writeFileAtomic(b.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save bookmarks: %w", err)
+	}
+	return nil
+}
+
+// List returns the bookmarks in collection tag, or all of them when tag is
+// empty, most recently added first
+func (b *Bookmarks) List(tag string) []Bookmark {
+	list := make([]Bookmark, 0, len(b.items))
+	for _, item := range b.items {
+		if tag == "" || item.HasTag(tag) {
+			list = append(list, item)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Added.After(list[j].Added)
+	})
+	return list
+}
+
+// Has reports whether any bookmark points into name(section)
+func (b *Bookmarks) Has(name, section string) bool {
+	return b.pages[fmt.Sprintf("%s(%s)", name, section)]
+}
+
+// Tags returns every collection in use, sorted
+func (b *Bookmarks) Tags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, item := range b.items {
+		for _, tag := range item.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// find returns the index of the bookmark pointing where bookmark does, or -1
+func (b *Bookmarks) find(bookmark Bookmark) int {
+	for i, item := range b.items {
+		if item.sameTarget(bookmark) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add saves a new bookmark; it returns false if one already points there
+func (b *Bookmarks) Add(bookmark Bookmark) (bool, error) {
+	if bookmark.Added.IsZero() {
+		bookmark.Added = time.Now()
+	}
+	added := false
+	err := b.update(func() {
+		if b.find(bookmark) < 0 {
+			b.items = append(b.items, bookmark)
+			added = true
+		}
+	})
+	return added, err
+}
+
+// Remove deletes the bookmark pointing where bookmark does
+func (b *Bookmarks) Remove(bookmark Bookmark) error {
+	return b.update(func() {
+		if i := b.find(bookmark); i >= 0 {
+			b.items = append(b.items[:i], b.items[i+1:]...)
+		}
+	})
+}
+
+// TogglePage bookmarks a whole page, or removes its page bookmark if it has
+// one; it returns whether the page is now bookmarked
+func (b *Bookmarks) TogglePage(page ManPage) (bool, error) {
+	bookmark := Bookmark{Name: page.Name, Section: page.Section, Added: time.Now()}
+	bookmarked := false
+	err := b.update(func() {
+		if i := b.find(bookmark); i >= 0 {
+			b.items = append(b.items[:i], b.items[i+1:]...)
+			return
+		}
+		b.items = append(b.items, bookmark)
+		bookmarked = true
+	})
+	return bookmarked, err
+}
+
+// SetTags replaces the collections of the bookmark pointing where bookmark does
+func (b *Bookmarks) SetTags(bookmark Bookmark, tags []string) error {
+	return b.update(func() {
+		if i := b.find(bookmark); i >= 0 {
+			b.items[i].Tags = tags
+		}
+	})
+}
+
+// Export writes the bookmarks in collection tag, or all of them, as JSON
+// that Import accepts
+func (b *Bookmarks) Export(w io.Writer, tag string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bookmarkFile{Version: 1, Bookmarks: b.List(tag)})
+}
+
+// Import merges an exported reading list into the bookmarks, adding every
+// imported bookmark to collection tag if it isn't empty. Bookmarks that
+// already exist gain the imported tags; it returns how many were new.
+func (b *Bookmarks) Import(r io.Reader, tag string) (int, error) {
+	var file bookmarkFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return 0, fmt.Errorf("invalid bookmarks JSON: %w", err)
+	}
+	for i, bookmark := range file.Bookmarks {
+		if bookmark.Name == "" || bookmark.Section == "" {
+			return 0, fmt.Errorf("bookmark %d has no page name or section", i+1)
+		}
+	}
+
+	added := 0
+	err := b.update(func() {
+		for _, bookmark := range file.Bookmarks {
+			bookmark.Tags = normalizeTags(append(bookmark.Tags, tag))
+			if i := b.find(bookmark); i >= 0 {
+				b.items[i].Tags = normalizeTags(append(b.items[i].Tags, bookmark.Tags...))
+				continue
+			}
+			if bookmark.Added.IsZero() {
+				bookmark.Added = time.Now()
+			}
+			b.items = append(b.items, bookmark)
+			added++
+		}
+	})
+	return added, err
+}
+
+// parseTags reads a comma-separated list of collections, e.g. "networking, onboarding"
+func parseTags(text string) []string {
+	return normalizeTags(strings.Split(text, ","))
+}
+
+// normalizeTags lower-cases, trims, sorts and de-duplicates tags
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// headingAnchor returns the section heading at or above line and how far
+// below it line is; with no heading above, the offset is from the top
+func headingAnchor(lines []string, line int) (string, int) {
+	if line >= len(lines) {
+		line = len(lines) - 1
+	}
+	for i := line; i >= 0; i-- {
+		if isSectionHeading(lines[i]) {
+			return strings.TrimSpace(lines[i]), line - i
+		}
+	}
+	return "", max(line, 0)
+}
+
+// bookmarkLine returns the line of lines a bookmark points at, or the top of
+// the page if its heading no longer exists
+func bookmarkLine(lines []string, bookmark Bookmark) int {
+	line := bookmark.Line
+	if bookmark.Heading != "" {
+		line = -1
+		for i, text := range lines {
+			if isSectionHeading(text) && strings.TrimSpace(text) == bookmark.Heading {
+				line = i + bookmark.Line
+				break
+			}
+		}
+		if line < 0 {
+			return 0
+		}
+	}
+	return max(min(line, len(lines)-1), 0)
+}
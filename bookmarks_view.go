@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toggleBookmark bookmarks page, or removes its page bookmark
+func (m *Model) toggleBookmark(page ManPage) {
+	bookmarked, err := m.bookmarks.TogglePage(page)
+	switch {
+	case err != nil:
+		m.err = err
+	case bookmarked:
+		m.status = fmt.Sprintf("Bookmarked %s(%s)", page.Name, page.Section)
+	default:
+		m.status = fmt.Sprintf("Removed bookmark on %s(%s)", page.Name, page.Section)
+	}
+}
+
+// currentPosition returns the line at the top of the detail view, under the
+// section heading it belongs to
+func (m Model) currentPosition() Bookmark {
+	heading, offset := headingAnchor(strings.Split(m.currentContent, "\n"), m.topLine())
+	return Bookmark{
+		Name:    m.currentPage.Name,
+		Section: m.currentPage.Section,
+		Heading: heading,
+		Line:    offset,
+	}
+}
+
+// bookmarkPosition bookmarks the line at the top of the detail view. At the
+// top of the page the whole page is bookmarked.
+func (m *Model) bookmarkPosition() {
+	bookmark := m.currentPosition()
+	added, err := m.bookmarks.Add(bookmark)
+	switch {
+	case err != nil:
+		m.status = err.Error()
+	case added:
+		m.status = "Bookmarked " + bookmark.String()
+	default:
+		m.status = "Already bookmarked"
+	}
+}
+
+// openBookmarks shows the bookmarks view over the current one
+func (m *Model) openBookmarks() {
+	m.bookmarkReturn = m.mode
+	m.mode = bookmarksView
+	m.editingTags = false
+	m.bookmarkCursor = 0
+	// Drop a collection whose last bookmark has gone
+	if m.bookmarkTag != "" && len(m.bookmarks.List(m.bookmarkTag)) == 0 {
+		m.bookmarkTag = ""
+	}
+}
+
+// cycleBookmarkTag moves the bookmarks view to the next or previous
+// collection, with "all" before the first
+func (m *Model) cycleBookmarkTag(step int) {
+	tags := append([]string{""}, m.bookmarks.Tags()...)
+	current := 0
+	for i, tag := range tags {
+		if tag == m.bookmarkTag {
+			current = i
+		}
+	}
+	m.bookmarkTag = tags[(current+step+len(tags))%len(tags)]
+	m.bookmarkCursor = 0
+}
+
+// updateBookmarksView handles keys while the bookmarks view is open
+func (m Model) updateBookmarksView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	bookmarks := m.bookmarks.List(m.bookmarkTag)
+
+	if m.editingTags {
+		switch msg.String() {
+		case "esc":
+			m.editingTags = false
+			m.tagInput.Blur()
+
+		case "enter":
+			m.editingTags = false
+			m.tagInput.Blur()
+			if m.bookmarkCursor < len(bookmarks) {
+				if err := m.bookmarks.SetTags(bookmarks[m.bookmarkCursor], parseTags(m.tagInput.Value())); err != nil {
+					m.status = err.Error()
+				}
+			}
+			// The bookmark may have left the collection being shown
+			if m.bookmarkTag != "" && len(m.bookmarks.List(m.bookmarkTag)) == 0 {
+				m.bookmarkTag = ""
+			}
+			m.bookmarkCursor = min(m.bookmarkCursor, max(len(m.bookmarks.List(m.bookmarkTag))-1, 0))
+
+		default:
+			var cmd tea.Cmd
+			m.tagInput, cmd = m.tagInput.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "q", "esc", "B":
+		m.mode = m.bookmarkReturn
+		// Bookmarks removed here leave the bookmarked-only list
+		if m.mode == listView && m.onlyBookmarked {
+			return m, m.refilter()
+		}
+
+	case "up", "k":
+		if m.bookmarkCursor > 0 {
+			m.bookmarkCursor--
+		}
+
+	case "down", "j":
+		if m.bookmarkCursor < len(bookmarks)-1 {
+			m.bookmarkCursor++
+		}
+
+	case "tab", "right", "l":
+		m.cycleBookmarkTag(1)
+
+	case "shift+tab", "left", "h":
+		m.cycleBookmarkTag(-1)
+
+	case "enter":
+		if m.bookmarkCursor < len(bookmarks) {
+			bookmark := bookmarks[m.bookmarkCursor]
+			m.pendingBookmark = &bookmark
+			return m, m.openPage(bookmark.Page())
+		}
+
+	case "d", "x":
+		if m.bookmarkCursor < len(bookmarks) {
+			bookmark := bookmarks[m.bookmarkCursor]
+			if err := m.bookmarks.Remove(bookmark); err != nil {
+				m.status = err.Error()
+			} else {
+				m.status = "Removed " + bookmark.String()
+			}
+			if m.bookmarkTag != "" && len(m.bookmarks.List(m.bookmarkTag)) == 0 {
+				m.bookmarkTag = ""
+			}
+			m.bookmarkCursor = min(m.bookmarkCursor, max(len(m.bookmarks.List(m.bookmarkTag))-1, 0))
+		}
+
+	case "t":
+		if m.bookmarkCursor < len(bookmarks) {
+			m.editingTags = true
+			m.tagInput.SetValue(strings.Join(bookmarks[m.bookmarkCursor].Tags, ", "))
+			m.tagInput.CursorEnd()
+			m.tagInput.Focus()
+			return m, textinput.Blink
+		}
+	}
+
+	return m, nil
+}
+
+// renderBookmarksView renders the bookmark list with its collections
+func (m Model) renderBookmarksView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(" Bookmarks "))
+	b.WriteString("\n\n")
+
+	// Collection tabs
+	activeStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("170")).
+		Bold(true)
+	b.WriteString("  Collections: ")
+	for i, tag := range append([]string{""}, m.bookmarks.Tags()...) {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		label := tag
+		if tag == "" {
+			label = "all"
+		}
+		label = fmt.Sprintf("%s (%d)", label, len(m.bookmarks.List(tag)))
+		if tag == m.bookmarkTag {
+			b.WriteString(activeStyle.Render("[" + label + "]"))
+		} else {
+			b.WriteString(statusStyle.Render(" " + label + " "))
+		}
+	}
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	}
+
+	bookmarks := m.bookmarks.List(m.bookmarkTag)
+	if len(bookmarks) == 0 {
+		b.WriteString(statusStyle.Render("  No bookmarks yet. Press m on a page in the list, or on a line while reading."))
+		b.WriteString("\n")
+	}
+
+	// Keep the cursor in a window of 20 rows
+	start := max(m.bookmarkCursor-10, 0)
+	end := min(start+20, len(bookmarks))
+	for i := start; i < end; i++ {
+		bookmark := bookmarks[i]
+		line := bookmark.String()
+		if len(bookmark.Tags) > 0 {
+			line = fmt.Sprintf("%-50s %s", line, strings.Join(bookmark.Tags, ", "))
+		}
+		if i == m.bookmarkCursor {
+			b.WriteString(selectedItemStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(itemStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.editingTags {
+		b.WriteString("\n  Collections (comma-separated): ")
+		b.WriteString(m.tagInput.View())
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("enter save • esc cancel"))
+		return b.String()
+	}
+
+	if m.status != "" {
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render("↑/k ↓/j select • enter open • tab/←/→ collection • t edit collections • d delete • esc back"))
+
+	return b.String()
+}
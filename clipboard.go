@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// copyToClipboard puts text on the system clipboard. It's always sent to the
+// terminal as an OSC 52 sequence, which works over SSH and in tmux but isn't
+// supported by every terminal, and also given to wl-copy or xclip when
+// there's a local display to copy to.
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	_, oscErr := seq.WriteTo(os.Stderr)
+
+	var cmd *exec.Cmd
+	switch {
+	case os.Getenv("WAYLAND_DISPLAY") != "":
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			cmd = exec.Command("wl-copy")
+		}
+	case os.Getenv("DISPLAY") != "":
+		if _, err := exec.LookPath("xclip"); err == nil {
+			cmd = exec.Command("xclip", "-selection", "clipboard")
+		}
+	}
+	if cmd == nil {
+		return oscErr
+	}
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil && oscErr != nil {
+		return errors.Join(oscErr, err)
+	}
+	return nil
+}
+
+// dedentLines joins lines with their common indentation and trailing spaces
+// removed, dropping blank lines at either end
+func dedentLines(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := lineIndent(line); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if len(line) >= indent {
+			line = line[indent:]
+		}
+		out[i] = line
+	}
+	return strings.Join(out, "\n")
+}
+
+// lineIndent returns the number of spaces a line starts with
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// synopsisLines returns the lines [start, end) of a page's SYNOPSIS, without
+// its heading
+func synopsisLines(lines []string) (int, int) {
+	start, end := sectionRange(lines, "SYNOPSIS")
+	if start == end {
+		return 0, 0
+	}
+	return start + 1, end
+}
+
+// optionEntryAt returns the lines [start, end) of the option entry line is
+// in, or the first one after it before limit: the line documenting its flags
+// and the more indented description after it. start == end if there's none.
+func optionEntryAt(lines []string, line, limit int) (int, int) {
+	isEntry := func(i int) bool {
+		trimmed := strings.TrimSpace(lines[i])
+		return strings.HasPrefix(trimmed, "-") && len(optionFlags(trimmed)) > 0
+	}
+	entryEnd := func(start int) int {
+		indent := lineIndent(lines[start])
+		end := start + 1
+		for end < len(lines) {
+			text := lines[end]
+			if strings.TrimSpace(text) != "" && (lineIndent(text) <= indent || isSectionHeading(text)) {
+				break
+			}
+			end++
+		}
+		// Blank lines after the entry aren't part of it
+		for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		return end
+	}
+
+	for i := min(line, len(lines)-1); i >= 0 && !isSectionHeading(lines[i]); i-- {
+		if isEntry(i) {
+			if end := entryEnd(i); end > line {
+				return i, end
+			}
+			break
+		}
+	}
+	for i := line; i < min(limit, len(lines)); i++ {
+		if isEntry(i) {
+			return i, entryEnd(i)
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+)
+
+// compareBlock is a run of lines aligned as a unit with the other page in a
+// comparison: a section heading, an option entry with its description, or
+// the lines between them
+type compareBlock struct {
+	start, end int      // lines [start, end)
+	heading    string   // set for a section heading
+	flags      []string // set for an option entry, e.g. ["-n", "--quiet"]
+}
+
+// matches reports whether b lines up with other: the same heading, or
+// option entries sharing a flag
+func (b compareBlock) matches(other compareBlock) bool {
+	if b.heading != "" {
+		return b.heading == other.heading
+	}
+	for _, flag := range b.flags {
+		for _, otherFlag := range other.flags {
+			if flag == otherFlag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareBlocks splits a page into headings, option entries and the lines
+// between them. Option entries are the least indented lines starting with a
+// dash in sections whose heading mentions options.
+func compareBlocks(lines []string) []compareBlock {
+	var blocks []compareBlock
+	inOptions := false
+	entryIndent := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case isSectionHeading(line):
+			blocks = append(blocks, compareBlock{start: i, end: i + 1, heading: trimmed})
+			inOptions = strings.Contains(trimmed, "OPTION")
+			entryIndent = -1
+			continue
+
+		case inOptions && strings.HasPrefix(trimmed, "-"):
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if entryIndent < 0 || indent <= entryIndent {
+				if flags := optionFlags(trimmed); len(flags) > 0 {
+					entryIndent = indent
+					blocks = append(blocks, compareBlock{start: i, end: i + 1, flags: flags})
+					continue
+				}
+			}
+		}
+
+		// Part of the entry or run of lines before it; headings stand alone
+		if n := len(blocks); n > 0 && blocks[n-1].heading == "" {
+			blocks[n-1].end = i + 1
+		} else {
+			blocks = append(blocks, compareBlock{start: i, end: i + 1})
+		}
+	}
+	return blocks
+}
+
+// optionFlags returns the flags an option entry documents, e.g. "-n, --quiet"
+// or "-e script, --expression=script". Anything after a run of spaces is the
+// start of its description.
+func optionFlags(entry string) []string {
+	if idx := strings.Index(entry, "  "); idx > 0 {
+		entry = entry[:idx]
+	}
+	var flags []string
+	fields := strings.FieldsFunc(entry, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '|'
+	})
+	for _, field := range fields {
+		if len(field) < 2 || field[0] != '-' || field == "--" {
+			continue
+		}
+		if idx := strings.IndexAny(field, "=["); idx > 0 {
+			field = field[:idx]
+		}
+		flags = append(flags, strings.TrimRight(field, ".:;"))
+	}
+	return flags
+}
+
+// alignPages lays two pages out side by side with matching headings and
+// option entries on the same rows. It returns the line shown on each row of
+// each side, -1 for padding, and the option entries each side has that the
+// other lacks anywhere. Entries the pages list in a different order can't all
+// line up, but aren't counted as missing.
+func alignPages(left, right []string) ([2][]int, [2]map[int]bool) {
+	blocks := [2][]compareBlock{compareBlocks(left), compareBlocks(right)}
+	a, b := blocks[0], blocks[1]
+
+	// Longest common subsequence of matching blocks
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].matches(b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var rows [2][]int
+	only := [2]map[int]bool{make(map[int]bool), make(map[int]bool)}
+	var flags [2]map[string]bool
+	for side := range blocks {
+		flags[side] = make(map[string]bool)
+		for _, block := range blocks[side] {
+			for _, flag := range block.flags {
+				flags[side][flag] = true
+			}
+		}
+	}
+	for side := range blocks {
+		for _, block := range blocks[side] {
+			if len(block.flags) == 0 {
+				continue
+			}
+			shared := false
+			for _, flag := range block.flags {
+				shared = shared || flags[1-side][flag]
+			}
+			if !shared {
+				only[side][block.start] = true
+			}
+		}
+	}
+
+	// emit lays blocks out next to each other, padding the shorter side
+	emit := func(sides [2][]compareBlock) {
+		var heights [2]int
+		for side, run := range sides {
+			for _, block := range run {
+				for line := block.start; line < block.end; line++ {
+					rows[side] = append(rows[side], line)
+				}
+				heights[side] += block.end - block.start
+			}
+		}
+		for side := range rows {
+			for n := heights[side]; n < max(heights[0], heights[1]); n++ {
+				rows[side] = append(rows[side], -1)
+			}
+		}
+	}
+
+	i, j := 0, 0
+	lastI, lastJ := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i].matches(b[j]) && lcs[i][j] == lcs[i+1][j+1]+1:
+			// The blocks between two matches go side by side
+			emit([2][]compareBlock{a[lastI:i], b[lastJ:j]})
+			emit([2][]compareBlock{a[i : i+1], b[j : j+1]})
+			i, j = i+1, j+1
+			lastI, lastJ = i, j
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	emit([2][]compareBlock{a[lastI:], b[lastJ:]})
+	return rows, only
+}
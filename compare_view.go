@@ -0,0 +1,453 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var onlyHereStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("114")).
+	Bold(true)
+
+// comparePane is one of the two pages in the comparison view
+type comparePane struct {
+	page    ManPage
+	key     renderKey
+	lines   []StyledLine
+	loaded  bool
+	err     error
+	offset  int          // first row shown
+	matches []int        // rows with search matches
+	only    map[int]bool // option entries the other page doesn't have
+}
+
+// comparison is the state of the comparison view
+type comparison struct {
+	panes      [2]comparePane
+	rows       [2][]int // line shown on each row; -1 for padding
+	focus      int      // pane that scrolls and searches
+	synced     bool     // both panes scroll together
+	aligned    bool     // headings and option entries line up
+	query      string
+	searching  bool // typing a search
+	returnMode viewMode
+}
+
+type compareLoadedMsg struct {
+	side    int
+	key     renderKey
+	content string
+	err     error
+}
+
+func loadComparePane(side int, key renderKey) tea.Cmd {
+	return func() tea.Msg {
+		content, err := renderPage(key.name, key.section, key.width)
+		return compareLoadedMsg{side: side, key: key, content: content, err: err}
+	}
+}
+
+// openCompare shows left and right side by side, scrolling together
+func (m *Model) openCompare(left, right ManPage) tea.Cmd {
+	m.endVisit()
+	m.compare = comparison{synced: true, returnMode: m.mode}
+	m.compare.panes[0].page = left
+	m.compare.panes[1].page = right
+	m.mode = compareView
+	return m.reflowCompare()
+}
+
+// compareWithTab compares the open page with page, or with the next tab
+// when page is the open page itself
+func (m *Model) compareWithTab(page ManPage) tea.Cmd {
+	if len(m.tabs) == 0 {
+		m.status = "Open a page first, then press c on another to compare them"
+		return nil
+	}
+	if page.Name == "" || (page.Name == m.currentPage.Name && page.Section == m.currentPage.Section) {
+		if len(m.tabs) < 2 {
+			m.status = "Open another page in a new tab (t) to compare with"
+			return nil
+		}
+		page = m.tabs[(m.activeTab+1)%len(m.tabs)].page
+	}
+	return m.openCompare(m.currentPage, page)
+}
+
+// comparePaneWidth is the width of each pane in the comparison view
+func (m Model) comparePaneWidth() int {
+	return max((m.width-3)/2, minRenderWidth)
+}
+
+// compareHeight is the number of rows each pane shows
+func (m Model) compareHeight() int {
+	return max(m.viewport.Height-1, 1)
+}
+
+// reflowCompare renders each page at the panes' width if it isn't already
+func (m *Model) reflowCompare() tea.Cmd {
+	var cmds []tea.Cmd
+	width := m.renderWidth(m.comparePaneWidth() - 2)
+	for side := range m.compare.panes {
+		pane := &m.compare.panes[side]
+		key := pageKey(pane.page, width)
+		if key == pane.key {
+			continue
+		}
+		pane.key = key
+		if content, ok := renderedPages.Get(key); ok {
+			m.setCompareContent(side, content)
+			continue
+		}
+		cmds = append(cmds, loadComparePane(side, key))
+	}
+	return tea.Batch(cmds...)
+}
+
+// setCompareContent shows content in a pane, keeping its reading position
+func (m *Model) setCompareContent(side int, content string) {
+	pane := &m.compare.panes[side]
+	oldLines := len(pane.lines)
+	line := m.compareTopLine(side)
+
+	pane.lines = ParseManFormatting(content)
+	pane.loaded = true
+	pane.err = nil
+	m.layoutCompare()
+	if oldLines > 0 {
+		m.scrollPaneToLine(side, line*len(pane.lines)/oldLines)
+	}
+}
+
+// layoutCompare works out the rows of both panes, aligned or line by line
+func (m *Model) layoutCompare() {
+	c := &m.compare
+	var text [2][]string
+	for side, pane := range c.panes {
+		text[side] = strings.Split(plainText(pane.lines), "\n")
+		if len(pane.lines) == 0 {
+			text[side] = nil
+		}
+	}
+
+	if c.aligned && c.panes[0].loaded && c.panes[1].loaded {
+		rows, only := alignPages(text[0], text[1])
+		c.rows = rows
+		c.panes[0].only, c.panes[1].only = only[0], only[1]
+	} else {
+		for side := range c.panes {
+			c.rows[side] = make([]int, len(text[side]))
+			for i := range c.rows[side] {
+				c.rows[side][i] = i
+			}
+			c.panes[side].only = nil
+		}
+	}
+	m.findCompareMatches()
+	for side := range c.panes {
+		c.panes[side].offset = m.clampCompareOffset(side, c.panes[side].offset)
+	}
+}
+
+// findCompareMatches marks the rows of each pane matching the search
+func (m *Model) findCompareMatches() {
+	query := strings.ToLower(m.compare.query)
+	for side := range m.compare.panes {
+		pane := &m.compare.panes[side]
+		pane.matches = nil
+		if query == "" {
+			continue
+		}
+		for row, line := range m.compare.rows[side] {
+			if line >= 0 && strings.Contains(strings.ToLower(pane.lines[line].Text), query) {
+				pane.matches = append(pane.matches, row)
+			}
+		}
+	}
+}
+
+func (m Model) clampCompareOffset(side, offset int) int {
+	return max(min(offset, len(m.compare.rows[side])-m.compareHeight()), 0)
+}
+
+// compareTopLine returns the page line at the top of a pane
+func (m Model) compareTopLine(side int) int {
+	rows := m.compare.rows[side]
+	for row := m.compare.panes[side].offset; row < len(rows); row++ {
+		if rows[row] >= 0 {
+			return rows[row]
+		}
+	}
+	return 0
+}
+
+// scrollPaneToLine scrolls a pane so page line is at the top
+func (m *Model) scrollPaneToLine(side, line int) {
+	for row, l := range m.compare.rows[side] {
+		if l >= line {
+			m.compare.panes[side].offset = m.clampCompareOffset(side, row)
+			return
+		}
+	}
+}
+
+// scrollCompare scrolls the focused pane by delta rows, and the other pane
+// with it when synced
+func (m *Model) scrollCompare(delta int) {
+	for side := range m.compare.panes {
+		if side == m.compare.focus || m.compare.synced {
+			pane := &m.compare.panes[side]
+			pane.offset = m.clampCompareOffset(side, pane.offset+delta)
+		}
+	}
+}
+
+// jumpCompareMatch scrolls the focused pane to its next match below the top
+// row, or the previous one above it when step is negative
+func (m *Model) jumpCompareMatch(step int) {
+	pane := m.compare.panes[m.compare.focus]
+	if len(pane.matches) == 0 {
+		m.status = fmt.Sprintf("No matches in %s(%s)", pane.page.Name, pane.page.Section)
+		return
+	}
+	target := -1
+	if step > 0 {
+		for _, row := range pane.matches {
+			if row > pane.offset {
+				target = row
+				break
+			}
+		}
+		if target < 0 {
+			target = pane.matches[0]
+		}
+	} else {
+		for i := len(pane.matches) - 1; i >= 0; i-- {
+			if pane.matches[i] < pane.offset {
+				target = pane.matches[i]
+				break
+			}
+		}
+		if target < 0 {
+			target = pane.matches[len(pane.matches)-1]
+		}
+	}
+	m.scrollCompare(target - pane.offset)
+}
+
+// updateCompareView handles keys while two pages are compared
+func (m Model) updateCompareView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := &m.compare
+
+	if c.searching {
+		switch msg.String() {
+		case "esc":
+			c.searching = false
+			m.detailSearchInput.Blur()
+
+		case "enter":
+			c.searching = false
+			m.detailSearchInput.Blur()
+			c.query = m.detailSearchInput.Value()
+			m.findCompareMatches()
+			if c.query != "" {
+				m.jumpCompareMatch(1)
+			}
+
+		default:
+			var cmd tea.Cmd
+			m.detailSearchInput, cmd = m.detailSearchInput.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	height := m.compareHeight()
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		if msg.String() == "esc" && c.query != "" {
+			c.query = ""
+			m.findCompareMatches()
+			break
+		}
+		m.mode = c.returnMode
+		if m.mode == detailView {
+			m.visitStart = time.Now()
+			return m, m.reflowDetail()
+		}
+
+	case "tab", "shift+tab":
+		c.focus = 1 - c.focus
+
+	case "up", "k":
+		m.scrollCompare(-1)
+
+	case "down", "j":
+		m.scrollCompare(1)
+
+	case "u", "ctrl+u":
+		m.scrollCompare(-height / 2)
+
+	case "d", "ctrl+d":
+		m.scrollCompare(height / 2)
+
+	case "pgup", "b":
+		m.scrollCompare(-height)
+
+	case "pgdown", "f", " ":
+		m.scrollCompare(height)
+
+	case "g":
+		m.scrollCompare(-c.panes[c.focus].offset)
+
+	case "G":
+		m.scrollCompare(len(c.rows[c.focus]))
+
+	case "s":
+		c.synced = !c.synced
+		if c.synced {
+			m.status = "Scrolling together"
+		} else {
+			m.status = "Scrolling separately"
+		}
+
+	case "a":
+		// Keep the focused pane's place, and line the other up with it
+		line := m.compareTopLine(c.focus)
+		c.aligned = !c.aligned
+		m.layoutCompare()
+		m.scrollPaneToLine(c.focus, line)
+		other := 1 - c.focus
+		if c.aligned {
+			c.panes[other].offset = m.clampCompareOffset(other, c.panes[c.focus].offset)
+		}
+
+	case "/":
+		c.searching = true
+		m.detailSearchInput.SetValue("")
+		m.detailSearchInput.Focus()
+		return m, textinput.Blink
+
+	case "n":
+		m.jumpCompareMatch(1)
+
+	case "N":
+		m.jumpCompareMatch(-1)
+	}
+
+	return m, nil
+}
+
+// renderCompareView renders the two pages side by side
+func (m Model) renderCompareView() string {
+	var b strings.Builder
+	c := m.compare
+
+	b.WriteString(titleStyle.Render(" Compare "))
+	modes := []string{"scrolling separately"}
+	if c.synced {
+		modes[0] = "scrolling together"
+	}
+	if c.aligned {
+		modes = append(modes, "options aligned")
+	}
+	if c.query != "" {
+		modes = append(modes, fmt.Sprintf("search: %s", c.query))
+	}
+	b.WriteString(statusStyle.Render("  " + strings.Join(modes, " · ")))
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+	b.WriteString("\n\n")
+
+	width := m.comparePaneWidth()
+	border := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(" │ ")
+
+	// Pane titles
+	var headers [2]string
+	for side, pane := range c.panes {
+		header := fmt.Sprintf("%s(%s)", pane.page.Name, pane.page.Section)
+		if side == c.focus {
+			header = titleStyle.Render(header)
+		} else {
+			header = tabStyle.Render(header)
+		}
+		if c.query != "" {
+			header += statusStyle.Render(fmt.Sprintf(" %d matches", len(pane.matches)))
+		}
+		headers[side] = header
+	}
+	b.WriteString(fitWidth(headers[0], width) + border + headers[1] + "\n")
+
+	for i := 0; i < m.compareHeight(); i++ {
+		b.WriteString(fitWidth(m.renderCompareRow(0, c.panes[0].offset+i), width))
+		b.WriteString(border)
+		b.WriteString(fitWidth(m.renderCompareRow(1, c.panes[1].offset+i), width))
+		b.WriteString("\n")
+	}
+
+	if c.searching {
+		b.WriteString("\n  ")
+		b.WriteString(m.detailSearchInput.View())
+		b.WriteString(helpStyle.Render("enter search both pages • esc cancel"))
+		return b.String()
+	}
+	b.WriteString(helpStyle.Render("↑/k ↓/j scroll • u/d half page • tab switch pane • s sync scrolling • a align options • / search both • n/N next/prev match • q/esc back"))
+	return b.String()
+}
+
+// renderCompareRow renders one row of a pane
+func (m Model) renderCompareRow(side, row int) string {
+	pane := m.compare.panes[side]
+	switch {
+	case pane.err != nil:
+		if row == 0 {
+			return renderError(pane.err)
+		}
+		return ""
+	case !pane.loaded:
+		if row == 0 {
+			return "  Loading..."
+		}
+		return ""
+	case row >= len(m.compare.rows[side]):
+		return ""
+	}
+
+	gutter := ""
+	if m.compare.aligned {
+		gutter = "  "
+	}
+	line := m.compare.rows[side][row]
+	if line < 0 {
+		return gutter
+	}
+	if pane.only[line] {
+		gutter = onlyHereStyle.Render("+ ")
+	}
+
+	highlightStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color("226")).
+		Foreground(lipgloss.Color("0")).
+		Bold(true)
+	var terms []string
+	if m.compare.query != "" {
+		terms = []string{m.compare.query}
+	}
+	styled := pane.lines[line]
+	return gutter + renderStyledLine(styled, termRanges(styled.Text, terms), highlightStyle)
+}
+
+// fitWidth pads or truncates a rendered line to width columns
+func fitWidth(line string, width int) string {
+	line = lipgloss.NewStyle().MaxWidth(width).Render(line)
+	if n := lipgloss.Width(line); n < width {
+		line += strings.Repeat(" ", width-n)
+	}
+	return line
+}
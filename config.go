@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config holds user settings from config.json in the config directory
+type Config struct {
+	// MaxWidth caps the width pages are rendered at, so lines stay readable
+	// on wide terminals; 0 renders at the full width of the pane
+	MaxWidth int `json:"max_width"`
+
+	// History records the pages opened, to rank them higher in searches and
+	// list them as recent
+	History bool `json:"history"`
+
+	// NotesDir is where notes on pages are kept, one plain text file per
+	// page; "" keeps them in the data directory
+	NotesDir string `json:"notes_dir"`
+
+	// SmartCase makes searches in a page case-sensitive when the query has
+	// capitals; alt+c toggles it while typing a search
+	SmartCase bool `json:"smart_case"`
+
+	// Mouse turns on the mouse: the wheel scrolls, clicks select and open
+	// pages, toggle section filters and follow cross-references, and the
+	// divider beside the preview can be dragged
+	Mouse bool `json:"mouse"`
+}
+
+// defaultConfig returns the settings used when there is no config file
+func defaultConfig() Config {
+	return Config{
+		MaxWidth:  100,
+		History:   true,
+		SmartCase: true,
+		Mouse:     true,
+	}
+}
+
+// LoadConfig reads the user's config file over the defaults. A missing file
+// is not an error; an unreadable or invalid one returns the defaults with
+// the error.
+func LoadConfig() (Config, error) {
+	cfg := defaultConfig()
+	dir, err := configDir()
+	if err != nil {
+		return cfg, nil
+	}
+
+	path := filepath.Join(dir, "config.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	diffChangedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+)
+
+// pageChanges is the state of the diff view
+type pageChanges struct {
+	page    ManPage
+	lines   []string // the formatted diff
+	offset  int      // first line shown
+	loading bool
+	err     error
+}
+
+type diffLoadedMsg struct {
+	page  ManPage
+	lines []string
+	err   error
+}
+
+// changedPagesMsg carries the pages changed since their last snapshot
+type changedPagesMsg struct {
+	pages map[string]bool
+}
+
+func loadDiff(snapshots *SnapshotStore, page ManPage) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := DiffInstalledPage(snapshots, page, time.Time{})
+		if err != nil {
+			return diffLoadedMsg{page: page, err: err}
+		}
+		return diffLoadedMsg{page: page, lines: diff.Format()}
+	}
+}
+
+func loadChangedPages(snapshots *SnapshotStore) tea.Cmd {
+	return func() tea.Msg {
+		return changedPagesMsg{pages: snapshots.Changed()}
+	}
+}
+
+// openDiff shows how the open page changed since its last differing snapshot
+func (m *Model) openDiff() tea.Cmd {
+	m.endVisit()
+	m.mode = diffView
+	m.changes = pageChanges{page: m.currentPage, loading: true}
+	return loadDiff(m.snapshots, m.currentPage)
+}
+
+// toggleOnlyChanged limits the list to pages changed since their last
+// snapshot, working out which those are the first time
+func (m *Model) toggleOnlyChanged() tea.Cmd {
+	m.onlyChanged = !m.onlyChanged
+	if m.onlyChanged && m.changedPages == nil {
+		m.status = "Checking pages against their snapshots..."
+		return loadChangedPages(m.snapshots)
+	}
+	return m.refilter()
+}
+
+// diffHeight is the number of diff lines shown at once
+func (m Model) diffHeight() int {
+	return max(m.viewport.Height-1, 1)
+}
+
+// scrollDiff moves the diff view by delta lines
+func (m *Model) scrollDiff(delta int) {
+	m.changes.offset = max(min(m.changes.offset+delta, len(m.changes.lines)-m.diffHeight()), 0)
+}
+
+// updateDiffView handles keys in the diff view
+func (m Model) updateDiffView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc", "D":
+		m.mode = detailView
+		m.visitStart = time.Now()
+		return m, m.reflowDetail()
+
+	case "up", "k":
+		m.scrollDiff(-1)
+
+	case "down", "j":
+		m.scrollDiff(1)
+
+	case "u", "pgup":
+		m.scrollDiff(-m.diffHeight() / 2)
+
+	case "d", "pgdown":
+		m.scrollDiff(m.diffHeight() / 2)
+
+	case "g":
+		m.changes.offset = 0
+
+	case "G":
+		m.scrollDiff(len(m.changes.lines))
+
+	case "s":
+		// Snapshot the installed page now, so later upgrades diff against it
+		page := m.changes.page
+		added, err := m.snapshots.Record(page, findManPagePath(page.Name, page.Section))
+		if err == nil {
+			err = m.snapshots.Save(time.Time{})
+		}
+		switch {
+		case err != nil:
+			m.status = err.Error()
+		case added:
+			m.status = fmt.Sprintf("Snapshotted %s(%s)", page.Name, page.Section)
+		default:
+			m.status = fmt.Sprintf("%s(%s) is unchanged since its last snapshot", page.Name, page.Section)
+		}
+		m.changes.loading = true
+		m.changedPages = nil
+		if m.onlyChanged {
+			// The list is refiltered once the changed pages are known again
+			return m, tea.Batch(loadDiff(m.snapshots, page), loadChangedPages(m.snapshots))
+		}
+		return m, loadDiff(m.snapshots, page)
+	}
+
+	return m, nil
+}
+
+// renderDiffView renders the changes to a page by section and option
+func (m Model) renderDiffView() string {
+	var b strings.Builder
+	c := m.changes
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf(" Changes: %s(%s) ", c.page.Name, c.page.Section)))
+	b.WriteString("\n\n")
+
+	switch {
+	case c.loading:
+		b.WriteString(statusStyle.Render("  Comparing with the last snapshot..."))
+		b.WriteString("\n")
+	case c.err != nil:
+		b.WriteString(errorStyle.Render("  " + c.err.Error()))
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render("  Press s to snapshot this page, or run lazyman --snapshot --all after upgrades."))
+		b.WriteString("\n")
+	default:
+		end := min(c.offset+m.diffHeight(), len(c.lines))
+		for _, line := range c.lines[c.offset:end] {
+			b.WriteString(renderDiffLine(fitWidth(line, m.width-1)))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.status != "" {
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render("↑/k ↓/j scroll • u/d half page • g/G top/bottom • s snapshot now • esc back"))
+
+	return b.String()
+}
+
+// renderDiffLine colours a formatted diff line by its marker
+func renderDiffLine(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(trimmed) < 2 || trimmed[1] != ' ' {
+		return line
+	}
+	switch diffStatus(trimmed[0]) {
+	case diffAdded:
+		return diffAddedStyle.Render(line)
+	case diffRemoved:
+		return diffRemovedStyle.Render(line)
+	case diffChanged:
+		return diffChangedStyle.Render(line)
+	}
+	return line
+}
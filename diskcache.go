@@ -0,0 +1,277 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	diskCacheMaxBytes = 200 << 20 // evict least recently used entries beyond this
+
+	// roffRendererVersion must change whenever ParseRoff's output changes,
+	// so plain text cached by older versions isn't reused
+	roffRendererVersion = "roff-1"
+)
+
+// DiskCache stores rendered pages as files named by the hash of everything
+// that affects the output, so stale entries are never read, only evicted
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	size int64 // bytes on disk; -1 until the directory has been scanned
+}
+
+// DiskCacheStats describes the contents of the on-disk cache
+type DiskCacheStats struct {
+	Dir     string
+	Entries int
+	Bytes   int64
+}
+
+var renderDiskCache = newDiskCache()
+
+func newDiskCache() *DiskCache {
+	dir, err := cacheDir()
+	if err != nil {
+		// No cache directory: every lookup misses and nothing is stored
+		return &DiskCache{}
+	}
+	return &DiskCache{dir: filepath.Join(dir, "pages"), maxBytes: diskCacheMaxBytes, size: -1}
+}
+
+// diskCacheKey identifies path as rendered by renderer at width, changing
+// whenever the source file does. It fails if path can't be read.
+func diskCacheKey(path, renderer string, width int) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	id := fmt.Sprintf("%s\x00%d\x00%d\x00%s\x00%d", path, info.ModTime().UnixNano(), info.Size(), renderer, width)
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// Get returns the entry for key, marking it recently used
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	path := filepath.Join(c.dir, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Put stores data under key, evicting old entries if the cache grows past
+// its size limit. Failures are ignored; the cache is only an optimisation.
+func (c *DiskCache) Put(key string, data []byte) {
+	if c.dir == "" {
+		return
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, key), data); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size < 0 {
+		c.size = c.scan()
+	} else {
+		c.size += int64(len(data))
+	}
+	if c.size > c.maxBytes {
+		c.evict()
+	}
+}
+
+// scan returns the bytes currently used; the caller holds c.mu
+func (c *DiskCache) scan() int64 {
+	var total int64
+	for _, entry := range c.entries() {
+		total += entry.Size()
+	}
+	return total
+}
+
+// entries returns the cache files, skipping writes in progress, which are
+// hidden
+func (c *DiskCache) entries() []os.FileInfo {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil
+	}
+	infos := make([]os.FileInfo, 0, len(dirEntries))
+	for _, entry := range dirEntries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// evict removes the least recently used entries until the cache is back
+// under 90% of its limit, leaving room before the next eviction; the
+// caller holds c.mu
+func (c *DiskCache) evict() {
+	infos := c.entries()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	c.size = 0
+	for _, info := range infos {
+		c.size += info.Size()
+	}
+	target := c.maxBytes / 10 * 9
+	for _, info := range infos {
+		if c.size <= target {
+			break
+		}
+		if os.Remove(filepath.Join(c.dir, info.Name())) == nil {
+			c.size -= info.Size()
+		}
+	}
+}
+
+// Stats counts the entries in the cache
+func (c *DiskCache) Stats() DiskCacheStats {
+	stats := DiskCacheStats{Dir: c.dir}
+	if c.dir == "" {
+		return stats
+	}
+	for _, info := range c.entries() {
+		stats.Entries++
+		stats.Bytes += info.Size()
+	}
+	return stats
+}
+
+// Clear removes every entry from the cache
+func (c *DiskCache) Clear() error {
+	if c.dir == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	c.size = 0
+	return nil
+}
+
+var (
+	manVersionOnce sync.Once
+	manVersion     string
+)
+
+// manRendererVersion identifies the installed man and the way lazyman runs
+// it, so pages rendered differently before are rendered again
+func manRendererVersion() string {
+	manVersionOnce.Do(func() {
+		manVersion = "man"
+		if output, err := exec.Command("man", "--version").Output(); err == nil {
+			if line, _, _ := strings.Cut(string(output), "\n"); line != "" {
+				manVersion = line
+			}
+		}
+		manVersion += " keep-formatting"
+	})
+	return manVersion
+}
+
+// globEscaper quotes the characters filepath.Glob treats specially, for pages
+// such as "[" (test)
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// findManPagePath returns the source file of name(section), looking in the
+// man directories before asking man -w
+func findManPagePath(name, section string) string {
+	dirs := []string{"man" + section}
+	if len(section) > 1 {
+		// e.g. 3pm pages live in man3
+		dirs = append(dirs, "man"+section[:1])
+	}
+	for _, manPath := range getManPaths() {
+		for _, dir := range dirs {
+			matches, _ := filepath.Glob(filepath.Join(manPath, dir, globEscaper.Replace(name)+"."+section+"*"))
+			if len(matches) > 0 {
+				return matches[0]
+			}
+		}
+	}
+
+	output, err := exec.Command("man", "-w", section, name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// cachedManContent renders name(section) with man, reusing output cached on
+// disk by earlier sessions while the source file is unchanged
+func cachedManContent(name, section string, width int) (string, error) {
+	key, cacheable := "", false
+	if path := findManPagePath(name, section); path != "" {
+		key, cacheable = diskCacheKey(path, manRendererVersion(), width)
+	}
+	if cacheable {
+		if data, ok := renderDiskCache.Get(key); ok {
+			return string(data), nil
+		}
+	}
+
+	content, err := GetManContent(name, section, width)
+	if err != nil {
+		return "", err
+	}
+	if cacheable {
+		renderDiskCache.Put(key, []byte(content))
+	}
+	return content, nil
+}
+
+// parseManPageFile returns the plain text of a man page source file, reusing
+// text cached on disk while the file is unchanged
+func parseManPageFile(path string) (RoffPage, error) {
+	key, cacheable := diskCacheKey(path, roffRendererVersion, 0)
+	if cacheable {
+		if data, ok := renderDiskCache.Get(key); ok {
+			var page RoffPage
+			if json.Unmarshal(data, &page) == nil {
+				return page, nil
+			}
+		}
+	}
+
+	raw, err := GetRawManContent(path)
+	if err != nil {
+		return RoffPage{}, err
+	}
+	page := ParseRoff(raw)
+	if cacheable {
+		if data, err := json.Marshal(page); err == nil {
+			renderDiskCache.Put(key, data)
+		}
+	}
+	return page, nil
+}
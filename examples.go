@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strings"
+)
+
+// Example is a command from a page's examples
+type Example struct {
+	Line        int    // first line of the example in the page
+	End         int    // line after the example
+	Description string // the prose introducing it, if any
+	Command     string // the command, without prompts or common indentation
+}
+
+// isExamplesHeading reports whether a section heading is for examples, e.g.
+// EXAMPLES or USAGE EXAMPLES
+func isExamplesHeading(line string) bool {
+	return strings.Contains(strings.TrimSpace(line), "EXAMPLE")
+}
+
+// extractExamples finds the example commands on a page: the code blocks in
+// its examples sections, and indented lines starting with a "$ " prompt
+// anywhere else
+func extractExamples(lines []string) []Example {
+	var examples []Example
+	heading := -1
+	for i := 0; i < len(lines); {
+		if isSectionHeading(lines[i]) {
+			heading = i
+			i++
+			continue
+		}
+
+		if heading >= 0 && isExamplesHeading(lines[heading]) {
+			sectionEnd := i
+			for sectionEnd < len(lines) && !isSectionHeading(lines[sectionEnd]) {
+				sectionEnd++
+			}
+			start, end := sectionCodeBlock(lines, heading, sectionEnd, i, sectionEnd)
+			if start == end {
+				i = sectionEnd
+				continue
+			}
+			examples = append(examples, newExample(lines, heading, start, end))
+			i = end
+			continue
+		}
+
+		if !isPromptLine(lines[i]) {
+			i++
+			continue
+		}
+		// The commands' output is part of the example too
+		end := i + 1
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" && lineIndent(lines[end]) >= lineIndent(lines[i]) {
+			end++
+		}
+		examples = append(examples, newExample(lines, heading, i, end))
+		i = end
+	}
+	return examples
+}
+
+// isPromptLine reports whether a line is an indented shell command after a
+// "$ " prompt
+func isPromptLine(line string) bool {
+	return lineIndent(line) > 0 && strings.HasPrefix(strings.TrimSpace(line), "$ ")
+}
+
+// newExample makes an example of the lines [start, end). When some of them
+// have a "$ " prompt only those are kept, as the rest are their output.
+func newExample(lines []string, heading, start, end int) Example {
+	block := lines[start:end]
+	var commands []string
+	for _, line := range block {
+		if isPromptLine(line) {
+			trimmed := strings.TrimSpace(line)
+			commands = append(commands, strings.TrimSpace(strings.TrimPrefix(trimmed, "$")))
+		}
+	}
+	command := strings.Join(commands, "\n")
+	if len(commands) == 0 {
+		command = dedentLines(block)
+	}
+	return Example{
+		Line:        start,
+		End:         end,
+		Description: exampleDescription(lines, heading, start),
+		Command:     command,
+	}
+}
+
+// exampleDescription returns the paragraph of prose just above the example at
+// line start, with its spacing collapsed and a trailing colon dropped
+func exampleDescription(lines []string, heading, start int) string {
+	i := start - 1
+	for i > heading && strings.TrimSpace(lines[i]) == "" {
+		i--
+	}
+	indent := lineIndent(lines[start])
+	end := i + 1
+	for i > heading && strings.TrimSpace(lines[i]) != "" && lineIndent(lines[i]) < indent {
+		i--
+	}
+	if i+1 >= end {
+		return ""
+	}
+	description := collapseSpaces(strings.Join(lines[i+1:end], " "))
+	return strings.TrimSuffix(description, ":")
+}
+
+// codeBlockAt returns the lines [start, end) of the code block line is in, or
+// the first one after it before limit: a run of lines indented further than
+// the prose of their section, as commands in EXAMPLES are, that isn't the
+// description of an option. start == end if there's none.
+func codeBlockAt(lines []string, line, limit int) (int, int) {
+	heading := -1
+	for i := min(line, len(lines)-1); i >= 0; i-- {
+		if isSectionHeading(lines[i]) {
+			heading = i
+			break
+		}
+	}
+
+	for heading < min(limit, len(lines)) {
+		sectionEnd := len(lines)
+		for i := heading + 1; i < len(lines); i++ {
+			if isSectionHeading(lines[i]) {
+				sectionEnd = i
+				break
+			}
+		}
+		if start, end := sectionCodeBlock(lines, heading, sectionEnd, max(line, heading+1), limit); start < end {
+			return start, end
+		}
+		heading = sectionEnd
+	}
+	return 0, 0
+}
+
+// sectionCodeBlock returns the first code block in the section between
+// heading and sectionEnd that has lines from from to limit
+func sectionCodeBlock(lines []string, heading, sectionEnd, from, limit int) (int, int) {
+	// The prose is at the section's least indentation
+	prose := -1
+	for i := heading + 1; i < sectionEnd; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			if n := lineIndent(lines[i]); prose < 0 || n < prose {
+				prose = n
+			}
+		}
+	}
+	isCode := func(i int) bool {
+		return strings.TrimSpace(lines[i]) != "" && lineIndent(lines[i]) > prose
+	}
+
+	for i := from; i < min(limit, sectionEnd); i++ {
+		if !isCode(i) {
+			continue
+		}
+		start, end := i, i+1
+		for start > heading+1 && isCode(start-1) {
+			start--
+		}
+		for end < sectionEnd && isCode(end) {
+			end++
+		}
+		if above := strings.TrimSpace(lines[max(start-1, 0)]); !strings.HasPrefix(above, "-") || len(optionFlags(above)) == 0 {
+			return start, end
+		}
+		i = end
+	}
+	return 0, 0
+}
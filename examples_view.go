@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var exampleCodeStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("114"))
+
+// openExamples lists the examples on the open page
+func (m *Model) openExamples() {
+	m.examples = extractExamples(strings.Split(m.currentContent, "\n"))
+	m.exampleCursor = 0
+	m.exampleOffset = 0
+	// Start at the first example on screen or after it
+	top := m.topLine()
+	for i, example := range m.examples {
+		if example.End > top {
+			m.exampleCursor = i
+			break
+		}
+	}
+	m.mode = examplesView
+	m.moveExampleCursor(0)
+}
+
+// exampleRows is the number of rows an example takes in the list
+func exampleRows(example Example) int {
+	rows := strings.Count(example.Command, "\n") + 2
+	if example.Description != "" {
+		rows++
+	}
+	return rows
+}
+
+// examplesHeight is the number of rows for examples on screen
+func (m Model) examplesHeight() int {
+	return max(m.viewport.Height, 1)
+}
+
+// moveExampleCursor moves the selection by delta examples and keeps it on
+// screen
+func (m *Model) moveExampleCursor(delta int) {
+	if len(m.examples) == 0 {
+		m.exampleCursor, m.exampleOffset = 0, 0
+		return
+	}
+	m.exampleCursor = max(min(m.exampleCursor+delta, len(m.examples)-1), 0)
+	if m.exampleCursor < m.exampleOffset {
+		m.exampleOffset = m.exampleCursor
+	}
+	for m.exampleOffset < m.exampleCursor {
+		rows := 0
+		for _, example := range m.examples[m.exampleOffset : m.exampleCursor+1] {
+			rows += exampleRows(example)
+		}
+		if rows <= m.examplesHeight() {
+			break
+		}
+		m.exampleOffset++
+	}
+}
+
+// updateExamplesView handles keys while the page's examples are listed
+func (m Model) updateExamplesView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc", "E":
+		if m.pickExample {
+			m.quitting = true
+			return m, tea.Quit
+		}
+		m.mode = detailView
+
+	case "up", "k":
+		m.moveExampleCursor(-1)
+
+	case "down", "j":
+		m.moveExampleCursor(1)
+
+	case "g":
+		m.moveExampleCursor(-len(m.examples))
+
+	case "G":
+		m.moveExampleCursor(len(m.examples))
+
+	case "enter":
+		if m.exampleCursor >= len(m.examples) {
+			break
+		}
+		example := m.examples[m.exampleCursor]
+		if m.pickExample || m.widget != nil {
+			// Back to the shell, in place of the command line
+			m.picked = example.Command
+			m.pickedPoint = len([]rune(example.Command))
+			m.quitting = true
+			return m, tea.Quit
+		}
+		// Show the example in the page
+		m.mode = detailView
+		m.scrollToLine(example.Line)
+
+	case "y":
+		if m.exampleCursor < len(m.examples) {
+			return m, copyText(m.examples[m.exampleCursor].Command, "example")
+		}
+
+	case "p":
+		// Print the example once lazyman has quit
+		if m.exampleCursor < len(m.examples) {
+			m.picked = m.examples[m.exampleCursor].Command
+			m.pickedPoint = len([]rune(m.picked))
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// renderExamplesView renders the page's examples, each under the prose that
+// introduces it
+func (m Model) renderExamplesView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf(" Examples in %s(%s) ", m.currentPage.Name, m.currentPage.Section)))
+	b.WriteString(statusStyle.Render(fmt.Sprintf("  %d examples", len(m.examples))))
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+	b.WriteString("\n\n")
+
+	row := lipgloss.NewStyle().MaxWidth(m.width)
+	rows := 0
+	if len(m.examples) == 0 {
+		b.WriteString(statusStyle.Render("  No examples on this page"))
+		b.WriteString("\n")
+		rows++
+	}
+	for i := m.exampleOffset; i < len(m.examples); i++ {
+		example := m.examples[i]
+		if rows+exampleRows(example) > m.examplesHeight() && i > m.exampleOffset {
+			break
+		}
+		marker := "  "
+		if i == m.exampleCursor {
+			marker = selectedItemStyle.UnsetPaddingLeft().Render("▸ ")
+		}
+		if example.Description != "" {
+			b.WriteString(row.Render(marker + statusStyle.Render(example.Description)))
+			b.WriteString("\n")
+			marker = "  "
+		}
+		for _, line := range strings.Split(example.Command, "\n") {
+			b.WriteString(row.Render(marker + "  " + exampleCodeStyle.Render(line)))
+			b.WriteString("\n")
+			marker = "  "
+		}
+		b.WriteString("\n")
+		rows += exampleRows(example)
+	}
+	for ; rows < m.examplesHeight(); rows++ {
+		b.WriteString("\n")
+	}
+
+	var help string
+	if m.pickExample {
+		help = "↑/k ↓/j select • enter print and quit • y copy • esc quit"
+	} else if m.widget != nil {
+		help = "↑/k ↓/j select • enter use as command line • y copy • esc back"
+	} else {
+		help = "↑/k ↓/j select • enter show in page • y copy • p print and quit • esc back"
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
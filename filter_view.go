@@ -0,0 +1,395 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// startFilter opens the & prompt, from the page or from the filtered lines
+func (m *Model) startFilter() tea.Cmd {
+	if !m.filter.active {
+		m.filter = lineFilter{returnTo: m.currentPosition()}
+	}
+	m.mode = filterInputView
+	m.filterInput.SetValue(m.filter.query)
+	m.filterInput.CursorEnd()
+	m.filterInput.Focus()
+	m.find.err = nil
+	return textinput.Blink
+}
+
+// filterReferenceLine is the line whose section alt+s and s keep: the
+// selected line while filtering, or the top of the page
+func (m Model) filterReferenceLine() int {
+	if m.filter.active && m.filter.cursor < len(m.filter.lines) {
+		return m.filter.lines[m.filter.cursor]
+	}
+	return m.topLine()
+}
+
+// applyFilter works out the lines the filter keeps, keeping the selected line
+// where it can
+func (m *Model) applyFilter() {
+	selected := -1
+	if m.filter.cursor < len(m.filter.lines) {
+		selected = m.filter.lines[m.filter.cursor]
+	}
+	m.filter.lines = filterLines(strings.Split(m.currentContent, "\n"), m.filter.pattern, m.filter.section, m.filter.context)
+	m.filter.cursor = 0
+	for i, line := range m.filter.lines {
+		if line >= selected {
+			m.filter.cursor = i
+			break
+		}
+	}
+	m.moveFilterCursor(0)
+}
+
+// closeFilter drops the filter, showing the whole page where it was
+func (m *Model) closeFilter() tea.Cmd {
+	m.mode = detailView
+	m.scrollToLine(bookmarkLine(strings.Split(m.currentContent, "\n"), m.filter.returnTo))
+	m.filter = lineFilter{}
+	return m.reflowDetail()
+}
+
+// filterHeight is the number of filtered lines on screen
+func (m Model) filterHeight() int {
+	return max(m.viewport.Height, 1)
+}
+
+// moveFilterCursor moves the selection by delta lines, stepping over the
+// separators between runs, and keeps it on screen
+func (m *Model) moveFilterCursor(delta int) {
+	f := &m.filter
+	if len(f.lines) == 0 {
+		f.cursor, f.offset = 0, 0
+		return
+	}
+	f.cursor = max(min(f.cursor+delta, len(f.lines)-1), 0)
+	if f.lines[f.cursor] < 0 {
+		// Separators are never first or last
+		if delta < 0 {
+			f.cursor--
+		} else {
+			f.cursor++
+		}
+	}
+	height := m.filterHeight()
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	}
+	if f.cursor >= f.offset+height {
+		f.offset = f.cursor - height + 1
+	}
+	f.offset = max(min(f.offset, len(f.lines)-height), 0)
+}
+
+// jumpFilterMatch selects the next line shown with a search match, or the
+// previous one when step is negative; a step of 0 starts from the selected
+// line itself
+func (m *Model) jumpFilterMatch(step int) {
+	n := len(m.filter.lines)
+	if len(m.searchMatches) == 0 || n == 0 {
+		return
+	}
+	// Next the other matches on the selected line
+	if selected := m.filter.lines[m.filter.cursor]; step != 0 {
+		next := m.currentMatch + step
+		if next >= 0 && next < len(m.searchMatches) && m.searchMatches[next].line == selected &&
+			m.searchMatches[m.currentMatch].line == selected {
+			m.currentMatch = next
+			return
+		}
+	}
+
+	direction := 1
+	if step < 0 {
+		direction = -1
+	}
+	for i := range n {
+		index := ((m.filter.cursor+step+i*direction)%n + n) % n
+		line := m.filter.lines[index]
+		if line < 0 {
+			continue
+		}
+		if matches, first := lineMatches(m.searchMatches, line); len(matches) > 0 {
+			m.currentMatch = first
+			if step < 0 {
+				m.currentMatch = first + len(matches) - 1
+			}
+			m.moveFilterCursor(index - m.filter.cursor)
+			return
+		}
+	}
+	m.status = "No search matches in the filtered lines"
+}
+
+// updateFilterInputView handles keys while a filter is typed at the & prompt
+func (m Model) updateFilterInputView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filterInput.Blur()
+		if m.filter.active {
+			m.mode = filterView
+			return m, nil
+		}
+		m.mode = detailView
+		return m, nil
+
+	case "enter":
+		query := m.filterInput.Value()
+		if query == "" && m.filter.section == "" {
+			// & with nothing clears the filter, as in less
+			m.filterInput.Blur()
+			return m, m.closeFilter()
+		}
+		m.filter.pattern = nil
+		if query != "" {
+			pattern, err := compileFind(query, m.find.flags)
+			if err != nil {
+				m.find.err = err
+				return m, nil
+			}
+			m.filter.pattern = pattern
+		}
+		m.filter.query = query
+		m.filter.active = true
+		m.filterInput.Blur()
+		m.mode = filterView
+		m.applyFilter()
+		return m, nil
+
+	case "alt+s":
+		if m.filter.section != "" {
+			m.filter.section = ""
+		} else {
+			m.filter.section = sectionHeading(strings.Split(m.currentContent, "\n"), m.filterReferenceLine())
+		}
+
+	case "alt+r":
+		m.find.flags.regex = !m.find.flags.regex
+
+	case "alt+c":
+		m.find.flags.smartCase = !m.find.flags.smartCase
+
+	case "alt+w":
+		m.find.flags.wholeWord = !m.find.flags.wholeWord
+
+	default:
+		var cmd tea.Cmd
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		m.find.err = nil
+		if query := m.filterInput.Value(); query != "" {
+			_, m.find.err = compileFind(query, m.find.flags)
+		}
+		return m, cmd
+	}
+
+	m.find.err = nil
+	if query := m.filterInput.Value(); query != "" {
+		_, m.find.err = compileFind(query, m.find.flags)
+	}
+	return m, nil
+}
+
+// updateFilterView handles keys while only the filtered lines are shown
+func (m Model) updateFilterView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		return m, m.closeFilter()
+
+	case "enter":
+		// Show the selected line in the whole page
+		if m.filter.cursor < len(m.filter.lines) {
+			line := m.filter.lines[m.filter.cursor]
+			m.filter = lineFilter{}
+			m.mode = detailView
+			m.scrollToLine(line)
+			return m, m.reflowDetail()
+		}
+
+	case "up", "k":
+		m.moveFilterCursor(-1)
+
+	case "down", "j":
+		m.moveFilterCursor(1)
+
+	case "u", "pgup":
+		m.moveFilterCursor(-m.filterHeight() / 2)
+
+	case "d", "pgdown":
+		m.moveFilterCursor(m.filterHeight() / 2)
+
+	case "g":
+		m.moveFilterCursor(-len(m.filter.lines))
+
+	case "G":
+		m.moveFilterCursor(len(m.filter.lines))
+
+	case "+", "=":
+		if m.filter.pattern != nil && m.filter.context < maxFilterContext {
+			m.filter.context++
+			m.applyFilter()
+		}
+
+	case "-":
+		if m.filter.context > 0 {
+			m.filter.context--
+			m.applyFilter()
+		}
+
+	case "s":
+		// Keep only the selected line's section, or the whole page again
+		if m.filter.section != "" {
+			m.filter.section = ""
+		} else {
+			m.filter.section = sectionHeading(strings.Split(m.currentContent, "\n"), m.filterReferenceLine())
+		}
+		if m.filter.section == "" && m.filter.pattern == nil {
+			return m, m.closeFilter()
+		}
+		m.applyFilter()
+
+	case "&":
+		return m, m.startFilter()
+
+	case "/":
+		return m, m.startFind()
+
+	case "n":
+		m.jumpFilterMatch(1)
+
+	case "N":
+		m.jumpFilterMatch(-1)
+	}
+
+	return m, nil
+}
+
+// filterSummary describes the filter, e.g. "& gzip · 12 lines · context 2"
+func (m Model) filterSummary() string {
+	f := m.filter
+	var parts []string
+	if f.query != "" {
+		parts = append(parts, "& "+f.query)
+	}
+	if f.section != "" {
+		parts = append(parts, "in "+f.section)
+	}
+	shown := 0
+	for _, line := range f.lines {
+		if line >= 0 {
+			shown++
+		}
+	}
+	parts = append(parts, fmt.Sprintf("%d lines", shown))
+	if f.context > 0 {
+		parts = append(parts, fmt.Sprintf("context %d", f.context))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// renderFilterView renders the lines the filter keeps, each with its line
+// number in the page
+func (m Model) renderFilterView() string {
+	var b strings.Builder
+	f := m.filter
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf(" %s(%s) ", m.currentPage.Name, m.currentPage.Section)))
+	b.WriteString(statusStyle.Render("  " + m.filterSummary()))
+	if m.searchQuery != "" {
+		b.WriteString(statusStyle.Render("  " + m.matchIndicator()))
+	}
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+	b.WriteString("\n\n")
+
+	row := lipgloss.NewStyle().MaxWidth(m.width)
+	if len(f.lines) == 0 {
+		b.WriteString(statusStyle.Render("  No lines match"))
+		b.WriteString("\n")
+	}
+	end := min(f.offset+m.filterHeight(), len(f.lines))
+	for i := f.offset; i < end; i++ {
+		line := f.lines[i]
+		if line < 0 {
+			b.WriteString(statusStyle.Render("  --"))
+			b.WriteString("\n")
+			continue
+		}
+		marker := "  "
+		if i == f.cursor {
+			marker = selectedItemStyle.UnsetPaddingLeft().Render("▸ ")
+		}
+		number := statusStyle.Render(fmt.Sprintf("%5d ", line+1))
+		b.WriteString(row.Render(marker + number + m.renderFilteredLine(line)))
+		b.WriteString("\n")
+	}
+	for i := end - f.offset; i < m.filterHeight(); i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/k ↓/j select • enter show in page • +/- context • s this section only • & edit filter • / search • n/N next/prev match • esc whole page"))
+	return b.String()
+}
+
+// renderFilteredLine renders a page line with the search matches on it
+// highlighted, or the filter's own matches when nothing is searched for
+func (m Model) renderFilteredLine(line int) string {
+	styled := m.currentLines[line]
+	var ranges [][2]int
+	var styles []lipgloss.Style
+	if m.searchPattern != nil {
+		matches, first := lineMatches(m.searchMatches, line)
+		for j, match := range matches {
+			ranges = append(ranges, [2]int{match.start, match.end})
+			if first+j == m.currentMatch {
+				styles = append(styles, findCurrentStyle)
+			} else {
+				styles = append(styles, findMatchStyle)
+			}
+		}
+	} else if m.filter.pattern != nil {
+		for _, loc := range m.filter.pattern.FindAllStringIndex(styled.Text, -1) {
+			if loc[1] > loc[0] {
+				ranges = append(ranges, [2]int{loc[0], loc[1]})
+				styles = append(styles, findMatchStyle)
+			}
+		}
+	}
+	return renderHighlightedLine(styled, ranges, styles)
+}
+
+// renderFilterInputView renders the & prompt over the page
+func (m Model) renderFilterInputView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(" Filter Lines "))
+	b.WriteString("\n\n")
+
+	b.WriteString("  ")
+	b.WriteString(m.filterInput.View())
+	b.WriteString("\n")
+	b.WriteString(m.find.renderFindFlags())
+	if m.filter.section != "" {
+		b.WriteString(findFlagOnStyle.Render("   [x] only " + m.filter.section))
+		b.WriteString(statusStyle.Render(" alt+s"))
+	} else {
+		b.WriteString(statusStyle.Render("   [ ] this section only alt+s"))
+	}
+	b.WriteString("\n\n")
+
+	if m.find.err != nil && m.filterInput.Value() != "" {
+		b.WriteString(errorStyle.Render("  " + m.find.err.Error()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("enter show matching lines • empty enter shows the whole page • esc cancel"))
+	return b.String()
+}
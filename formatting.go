@@ -0,0 +1,239 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// textStyle is the set of emphasis flags a character of man output can carry
+type textStyle uint8
+
+const (
+	styleBold      textStyle = 1 << iota // commands, flags and literal text
+	styleUnderline                       // arguments and placeholders
+	styleItalic
+)
+
+// StyledLine is a line of man output with its formatting separated out;
+// Styles[i] is the style of the byte Text[i]
+type StyledLine struct {
+	Text   string
+	Styles []textStyle
+}
+
+// manStyles maps each combination of textStyle flags to the theme style it
+// is shown in
+var manStyles = func() [8]lipgloss.Style {
+	var styles [8]lipgloss.Style
+	for i := range styles {
+		style := lipgloss.NewStyle()
+		flags := textStyle(i)
+		if flags&styleBold != 0 {
+			style = style.Bold(true).Foreground(lipgloss.Color("75"))
+		}
+		if flags&styleUnderline != 0 {
+			style = style.Underline(true).Foreground(lipgloss.Color("150"))
+		}
+		if flags&styleItalic != 0 {
+			style = style.Italic(true).Foreground(lipgloss.Color("150"))
+		}
+		styles[i] = style
+	}
+	return styles
+}()
+
+// ParseManFormatting splits man output into lines of plain text and styles,
+// decoding backspace overstrike ("a\ba" is bold, "_\ba" underlined) and SGR
+// escapes for bold, italic and underline. Other escapes are dropped.
+func ParseManFormatting(raw string) []StyledLine {
+	var current textStyle // SGR state, which can span lines
+	rawLines := strings.Split(raw, "\n")
+	lines := make([]StyledLine, 0, len(rawLines))
+
+	for _, rawLine := range rawLines {
+		if !strings.ContainsAny(rawLine, "\b\x1b") && current == 0 {
+			lines = append(lines, StyledLine{Text: rawLine, Styles: make([]textStyle, len(rawLine))})
+			continue
+		}
+
+		var text strings.Builder
+		var styles []textStyle
+		runes := []rune(rawLine)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			if r == '\x1b' {
+				i = parseEscape(runes, i, &current)
+				continue
+			}
+			if r == '\b' {
+				// A stray backspace with nothing to overstrike
+				continue
+			}
+
+			style := current
+			// Overstrike: a character, a backspace, then what is printed over it
+			for i+2 < len(runes) && runes[i+1] == '\b' {
+				next := runes[i+2]
+				switch {
+				case r == next:
+					style |= styleBold
+				case r == '_':
+					style |= styleUnderline
+					r = next
+				case next == '_':
+					style |= styleUnderline
+				default:
+					// Overprinted by something else; the last character wins
+					r = next
+				}
+				i += 2
+			}
+
+			n, _ := text.WriteRune(r)
+			for ; n > 0; n-- {
+				styles = append(styles, style)
+			}
+		}
+		lines = append(lines, StyledLine{Text: text.String(), Styles: styles})
+	}
+	return lines
+}
+
+// parseEscape applies or skips the escape sequence starting at runes[i] and
+// returns the index of its last rune
+func parseEscape(runes []rune, i int, style *textStyle) int {
+	if i+1 >= len(runes) {
+		return i
+	}
+
+	switch runes[i+1] {
+	case '[':
+		// CSI: parameters, then a final byte in @-~
+		end := i + 2
+		for end < len(runes) && (runes[end] < '@' || runes[end] > '~') {
+			end++
+		}
+		if end < len(runes) && runes[end] == 'm' {
+			applySGR(string(runes[i+2:end]), style)
+		}
+		return min(end, len(runes)-1)
+	case ']':
+		// OSC, e.g. hyperlinks: up to BEL or ESC backslash
+		for end := i + 2; end < len(runes); end++ {
+			if runes[end] == '\a' {
+				return end
+			}
+			if runes[end] == '\x1b' && end+1 < len(runes) && runes[end+1] == '\\' {
+				return end + 1
+			}
+		}
+		return len(runes) - 1
+	default:
+		return i + 1
+	}
+}
+
+// applySGR updates style from the parameters of an SGR sequence
+func applySGR(params string, style *textStyle) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			code = 0 // an empty parameter means reset
+		}
+		switch code {
+		case 0:
+			*style = 0
+		case 1:
+			*style |= styleBold
+		case 3:
+			*style |= styleItalic
+		case 4:
+			*style |= styleUnderline
+		case 22:
+			*style &^= styleBold
+		case 23:
+			*style &^= styleItalic
+		case 24:
+			*style &^= styleUnderline
+		case 38, 48, 58:
+			// Extended colours carry their own parameters; skip them so they
+			// aren't read as style codes
+			if i+1 < len(codes) && codes[i+1] == "5" {
+				i += 2
+			} else if i+1 < len(codes) && codes[i+1] == "2" {
+				i += 4
+			}
+		}
+	}
+}
+
+// plainText joins the text of lines, dropping their styles
+func plainText(lines []StyledLine) string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// renderStyledLines renders every line with its theme styles
+func renderStyledLines(lines []StyledLine) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(renderStyledLine(line, nil, lipgloss.Style{}))
+	}
+	return b.String()
+}
+
+// renderStyledLine renders a line with its theme styles, drawing the byte
+// ranges in highlights with highlight instead
+func renderStyledLine(line StyledLine, highlights [][2]int, highlight lipgloss.Style) string {
+	styles := make([]lipgloss.Style, len(highlights))
+	for i := range styles {
+		styles[i] = highlight
+	}
+	return renderHighlightedLine(line, highlights, styles)
+}
+
+// renderHighlightedLine renders a line with its theme styles, drawing each of
+// the byte ranges in highlights with its own style from styles
+func renderHighlightedLine(line StyledLine, highlights [][2]int, styles []lipgloss.Style) string {
+	var b strings.Builder
+	h := 0
+	for start := 0; start < len(line.Text); {
+		for h < len(highlights) && highlights[h][1] <= start {
+			h++
+		}
+		highlighted := h < len(highlights) && highlights[h][0] <= start
+
+		// Extend the run while the style and highlighting stay the same
+		end := start + 1
+		for end < len(line.Text) && line.Styles[end] == line.Styles[start] {
+			if highlighted && end >= highlights[h][1] {
+				break
+			}
+			if !highlighted && h < len(highlights) && end >= highlights[h][0] {
+				break
+			}
+			end++
+		}
+
+		text := line.Text[start:end]
+		switch {
+		case highlighted:
+			b.WriteString(styles[h].Render(text))
+		case line.Styles[start] == 0:
+			b.WriteString(text)
+		default:
+			b.WriteString(manStyles[line.Styles[start]].Render(text))
+		}
+		start = end
+	}
+	return b.String()
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scores for fuzzy matching, modelled on fzf's: every matched character
+// scores, characters at word boundaries and runs of consecutive characters
+// earn bonuses, and gaps between matched characters cost a little
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	bonusBoundary     = 8
+	bonusConsecutive  = 4
+	bonusFirstChar    = 2 // multiplier for the first character's bonus
+	bonusNamePrefix   = 50
+	bonusExactName    = 100
+)
+
+// fuzzyTerm is one space-separated term of a filter pattern
+type fuzzyTerm struct {
+	text          string
+	prefix        bool // ^text: the name starts with text
+	suffix        bool // text$: the name ends with text
+	exact         bool // 'text: text appears as a substring
+	negate        bool // !text: pages containing text are excluded
+	caseSensitive bool
+}
+
+// FuzzyResult is a page that matched a filter pattern, with the byte offsets
+// of the matched characters in its name and description
+type FuzzyResult struct {
+	Page    ManPage
+	Score   int
+	NamePos []int
+	DescPos []int
+}
+
+// parseFuzzyPattern splits a pattern into terms using fzf's extended syntax:
+// ^prefix, suffix$, 'exact and !negate
+func parseFuzzyPattern(pattern string) []fuzzyTerm {
+	var terms []fuzzyTerm
+	for _, word := range strings.Fields(pattern) {
+		var term fuzzyTerm
+		if strings.HasPrefix(word, "!") {
+			term.negate = true
+			// Negations always match literally, as in fzf
+			term.exact = true
+			word = word[1:]
+		}
+		switch {
+		case strings.HasPrefix(word, "'"):
+			term.exact = true
+			word = word[1:]
+		case strings.HasPrefix(word, "^"):
+			term.prefix = true
+			word = word[1:]
+		}
+		if strings.HasSuffix(word, "$") && len(word) > 1 {
+			term.suffix = true
+			word = word[:len(word)-1]
+		}
+		if word == "" {
+			continue
+		}
+		// Smart case: only patterns with upper-case letters are case sensitive
+		term.caseSensitive = word != strings.ToLower(word)
+		if !term.caseSensitive {
+			word = asciiLower(word)
+		}
+		term.text = word
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// FuzzyFilter returns the pages matching every term of pattern, best first
+func FuzzyFilter(pages []ManPage, pattern string) []FuzzyResult {
+	terms := parseFuzzyPattern(pattern)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []FuzzyResult
+	for _, page := range pages {
+		if result, ok := matchPage(page, terms); ok {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return len(results[i].Page.Name) < len(results[j].Page.Name)
+	})
+	return results
+}
+
+// matchPage matches every term against the page's name, falling back to its
+// description; description matches count for half as much
+func matchPage(page ManPage, terms []fuzzyTerm) (FuzzyResult, bool) {
+	result := FuzzyResult{Page: page}
+	nameLower := asciiLower(page.Name)
+	descLower := asciiLower(page.Description)
+
+	for _, term := range terms {
+		name, desc := page.Name, page.Description
+		if !term.caseSensitive {
+			name, desc = nameLower, descLower
+		}
+
+		if term.negate {
+			if matchAnchored(name, term) != nil || (!term.prefix && !term.suffix && strings.Contains(desc, term.text)) {
+				return result, false
+			}
+			continue
+		}
+
+		if term.prefix || term.suffix {
+			// Anchors refer to the page name
+			positions := matchAnchored(name, term)
+			if positions == nil {
+				return result, false
+			}
+			result.Score += exactScore(name, positions[0], len(term.text))
+			result.NamePos = append(result.NamePos, positions...)
+			continue
+		}
+
+		var score int
+		var positions []int
+		var ok bool
+		if term.exact {
+			positions = substringPositions(name, term.text)
+			if ok = positions != nil; ok {
+				score = exactScore(name, positions[0], len(term.text))
+			}
+		} else {
+			score, positions, ok = fuzzyMatch(name, term.text)
+		}
+		if ok {
+			if name == term.text {
+				score += bonusExactName
+			} else if strings.HasPrefix(name, term.text) {
+				score += bonusNamePrefix
+			}
+			result.Score += score
+			result.NamePos = append(result.NamePos, positions...)
+			continue
+		}
+
+		if term.exact {
+			positions = substringPositions(desc, term.text)
+			if ok = positions != nil; ok {
+				score = exactScore(desc, positions[0], len(term.text))
+			}
+		} else {
+			score, positions, ok = fuzzyMatch(desc, term.text)
+		}
+		if !ok {
+			return result, false
+		}
+		result.Score += score / 2
+		result.DescPos = append(result.DescPos, positions...)
+	}
+
+	sort.Ints(result.NamePos)
+	sort.Ints(result.DescPos)
+	return result, true
+}
+
+// matchAnchored returns the positions of an anchored or literal term in text,
+// or nil if it doesn't match
+func matchAnchored(text string, term fuzzyTerm) []int {
+	switch {
+	case term.prefix && term.suffix:
+		if text != term.text {
+			return nil
+		}
+		return span(0, len(text))
+	case term.prefix:
+		if !strings.HasPrefix(text, term.text) {
+			return nil
+		}
+		return span(0, len(term.text))
+	case term.suffix:
+		if !strings.HasSuffix(text, term.text) {
+			return nil
+		}
+		return span(len(text)-len(term.text), len(text))
+	default:
+		return substringPositions(text, term.text)
+	}
+}
+
+// substringPositions returns the positions of the first occurrence of pattern
+// in text, or nil
+func substringPositions(text, pattern string) []int {
+	idx := strings.Index(text, pattern)
+	if idx == -1 {
+		return nil
+	}
+	return span(idx, idx+len(pattern))
+}
+
+// span returns the offsets start..end-1
+func span(start, end int) []int {
+	positions := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		positions = append(positions, i)
+	}
+	return positions
+}
+
+// exactScore scores a contiguous match of n characters starting at start
+func exactScore(text string, start, n int) int {
+	return scoreMatch*n + bonusConsecutive*(n-1) + bonusAt(text, start)*bonusFirstChar
+}
+
+// fuzzyMatch finds pattern as a subsequence of text. Like fzf's v1 algorithm
+// it takes the first occurrence, then scans back from its end to find the
+// shortest window containing the pattern, and scores the match in that window.
+func fuzzyMatch(text, pattern string) (int, []int, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	// Forward scan: find where the first occurrence ends
+	pi := 0
+	end := -1
+	for i := 0; i < len(text); i++ {
+		if text[i] == pattern[pi] {
+			pi++
+			if pi == len(pattern) {
+				end = i
+				break
+			}
+		}
+	}
+	if end == -1 {
+		return 0, nil, false
+	}
+
+	// Backward scan: find the latest start that still contains the pattern
+	pi = len(pattern) - 1
+	start := end
+	for i := end; i >= 0; i-- {
+		if text[i] == pattern[pi] {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	// Score the match within the window
+	positions := make([]int, 0, len(pattern))
+	score := 0
+	pi = 0
+	prev := -1
+	for i := start; i <= end && pi < len(pattern); i++ {
+		if text[i] != pattern[pi] {
+			continue
+		}
+		bonus := bonusAt(text, i)
+		if pi == 0 {
+			bonus *= bonusFirstChar
+		}
+		score += scoreMatch + bonus
+		if prev != -1 {
+			if gap := i - prev - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score += scoreGapStart + scoreGapExtension*(gap-1)
+			}
+		}
+		positions = append(positions, i)
+		prev = i
+		pi++
+	}
+	return score, positions, true
+}
+
+// bonusAt rewards matches at the start of a word: the start of the text,
+// after punctuation or whitespace, or a letter following a digit
+func bonusAt(text string, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev, curr := text[i-1], text[i]
+	switch {
+	case !isAlnum(prev) && isAlnum(curr):
+		return bonusBoundary
+	case prev >= 'a' && prev <= 'z' && curr >= 'A' && curr <= 'Z':
+		return bonusBoundary / 2
+	case prev >= '0' && prev <= '9' && !(curr >= '0' && curr <= '9'):
+		return bonusBoundary / 2
+	}
+	return 0
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// asciiLower lower-cases ASCII letters only, so byte offsets stay valid for
+// the original string
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+var fuzzyTestPages = []ManPage{
+	{Name: "ls", Section: "1", Description: "list directory contents"},
+	{Name: "lsblk", Section: "8", Description: "list block devices"},
+	{Name: "lsof", Section: "8", Description: "list open files"},
+	{Name: "false", Section: "1", Description: "do nothing, unsuccessfully"},
+	{Name: "grep", Section: "1", Description: "print lines that match patterns"},
+	{Name: "zgrep", Section: "1", Description: "search possibly compressed files for a regular expression"},
+	{Name: "gzip", Section: "1", Description: "compress or expand files"},
+	{Name: "tar", Section: "1", Description: "an archiving utility"},
+	{Name: "XML", Section: "3pm", Description: "Perl extensions for XML"},
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		// An exact name comes first, then names it starts, then names it
+		// matches a subsequence of, then descriptions
+		{"ls", []string{"ls", "lsof", "lsblk", "false", "grep", "gzip", "XML", "zgrep"}},
+		{"grep", []string{"grep", "zgrep"}},
+		{"gz", []string{"gzip"}},
+		// Anchors and exact terms
+		{"^ls", []string{"ls", "lsof", "lsblk"}},
+		{"grep$", []string{"grep", "zgrep"}},
+		{"^grep$", []string{"grep"}},
+		{"'sblk", []string{"lsblk"}},
+		{"'lsb", []string{"lsblk"}},
+		// Negated terms drop pages matching them exactly
+		{"^ls !blk", []string{"ls", "lsof"}},
+		{"!s", []string{"tar"}},
+		// Every term must match, in the name or the description
+		{"ls 'open", []string{"lsof"}},
+		{"compressed", []string{"zgrep", "gzip"}},
+		{"'compressed", []string{"zgrep"}},
+		{"archiving", []string{"tar"}},
+		// Lower case patterns ignore case; any capital makes them case sensitive
+		{"xml", []string{"XML"}},
+		{"XML", []string{"XML"}},
+		{"Ls", nil},
+		{"", nil},
+		{"qqq", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			var got []string
+			for _, result := range FuzzyFilter(fuzzyTestPages, tt.pattern) {
+				got = append(got, result.Page.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FuzzyFilter(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFuzzyPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []fuzzyTerm
+	}{
+		{"ls", []fuzzyTerm{{text: "ls"}}},
+		{"^ls", []fuzzyTerm{{text: "ls", prefix: true}}},
+		{"ls$", []fuzzyTerm{{text: "ls", suffix: true}}},
+		{"'ls", []fuzzyTerm{{text: "ls", exact: true}}},
+		{"!ls", []fuzzyTerm{{text: "ls", exact: true, negate: true}}},
+		{"Ls", []fuzzyTerm{{text: "Ls", caseSensitive: true}}},
+		{"^tar  gz$", []fuzzyTerm{{text: "tar", prefix: true}, {text: "gz", suffix: true}}},
+	}
+	for _, tt := range tests {
+		if got := parseFuzzyPattern(tt.pattern); !slices.Equal(got, tt.want) {
+			t.Errorf("parseFuzzyPattern(%q) = %+v, want %+v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	historySamples  = 10   // recent visits kept per page for scoring
+	historyMaxPages = 1000 // pages remembered; the lowest scoring are forgotten
+	recentPageCount = 5    // pages in the "recent" section of the list
+
+	// dwellForFullWeight is how long a visit must last to count double;
+	// shorter visits count proportionally less extra
+	dwellForFullWeight = 2 * time.Minute
+
+	// frecencyCeiling is the score beyond which pages get no further boost
+	frecencyCeiling = 2000
+
+	// frecencyBoostWeight is how much the most read pages' deep search
+	// scores are raised by, as a fraction
+	frecencyBoostWeight = 0.5
+
+	// fuzzyFrecencyBonus is the filter score the most read pages gain,
+	// about as much as two and a half matched characters
+	fuzzyFrecencyBonus = 40
+)
+
+// Visit is one viewing of a page
+type Visit struct {
+	At    time.Time `json:"at"`
+	Dwell float64   `json:"dwell_seconds"`
+}
+
+// HistoryEntry is everything remembered about viewing one page
+type HistoryEntry struct {
+	Name    string  `json:"name"`
+	Section string  `json:"section"`
+	Visits  int     `json:"visits"`
+	Dwell   float64 `json:"dwell_seconds"` // total time spent reading
+	Recent  []Visit `json:"recent"`        // the latest visits, oldest first
+}
+
+// LastVisit returns when the page was last opened
+func (e HistoryEntry) LastVisit() time.Time {
+	if len(e.Recent) == 0 {
+		return time.Time{}
+	}
+	return e.Recent[len(e.Recent)-1].At
+}
+
+// Frecency scores how often and how recently the page was read, the way
+// Firefox ranks its history: the average weight of the latest visits, by
+// age and time spent reading, times the number of visits
+func (e HistoryEntry) Frecency(now time.Time) float64 {
+	if len(e.Recent) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, visit := range e.Recent {
+		weight := recencyWeight(now.Sub(visit.At))
+		weight *= 1 + math.Min(visit.Dwell/dwellForFullWeight.Seconds(), 1)
+		sum += weight
+	}
+	return float64(e.Visits) * sum / float64(len(e.Recent))
+}
+
+// recencyWeight weighs a visit by its age
+func recencyWeight(age time.Duration) float64 {
+	const day = 24 * time.Hour
+	switch {
+	case age < 4*day:
+		return 100
+	case age < 14*day:
+		return 70
+	case age < 31*day:
+		return 50
+	case age < 90*day:
+		return 30
+	default:
+		return 10
+	}
+}
+
+// frecencyBoost maps a frecency score onto 0..1 for boosting search ranks
+func frecencyBoost(frecency float64) float64 {
+	if frecency <= 0 {
+		return 0
+	}
+	return math.Min(math.Log1p(frecency)/math.Log1p(frecencyCeiling), 1)
+}
+
+// historyFile is the layout of history.json
+type historyFile struct {
+	Version int            `json:"version"`
+	Pages   []HistoryEntry `json:"pages"`
+}
+
+// History records the pages the user reads. A nil *History is history
+// disabled: it records nothing and boosts nothing.
+type History struct {
+	path    string
+	entries map[string]*HistoryEntry // keyed by "name(section)"
+}
+
+// LoadHistory reads the history file, which is empty if there's none
+func LoadHistory() (*History, error) {
+	h := &History{entries: make(map[string]*HistoryEntry)}
+	dir, err := dataDir()
+	if err != nil {
+		return h, fmt.Errorf("no data directory for history: %w", err)
+	}
+
+	path := filepath.Join(dir, "history.json")
+	entries, err := readHistoryFile(path)
+	if err != nil {
+		return h, err
+	}
+	h.path = path
+	h.entries = entries
+	return h, nil
+}
+
+// readHistoryFile reads the history at path, which is empty if there's none
+func readHistoryFile(path string) (map[string]*HistoryEntry, error) {
+	entries := make(map[string]*HistoryEntry)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return entries, fmt.Errorf("failed to read history: %w", err)
+	}
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return entries, fmt.Errorf("invalid history file %s: %w", path, err)
+	}
+	for i := range file.Pages {
+		entry := file.Pages[i]
+		entries[fmt.Sprintf("%s(%s)", entry.Name, entry.Section)] = &entry
+	}
+	return entries, nil
+}
+
+// update applies change to the history as saved and saves it, holding a
+// lock meanwhile, so that pages another lazyman recorded since the history
+// was loaded are kept rather than overwritten
+func (h *History) update(change func()) error {
+	if h.path == "" {
+		change()
+		return errors.New("history can't be saved: no usable history file")
+	}
+	unlock, err := lockFile(h.path+".lock", dataLockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	defer unlock()
+	entries, err := readHistoryFile(h.path)
+	if err != nil {
+		return err
+	}
+	h.entries = entries
+	change()
+	return h.save()
+}
+
+// save writes the history back to disk, forgetting the lowest scoring pages
+// beyond historyMaxPages. Call it from update.
+func (h *History) save() error {
+	entries := h.Entries(true)
+	if len(entries) > historyMaxPages {
+		for _, entry := range entries[historyMaxPages:] {
+			delete(h.entries, fmt.Sprintf("%s(%s)", entry.Name, entry.Section))
+		}
+		entries = entries[:historyMaxPages]
+	}
+
+	data, err := json.MarshalIndent(historyFile{Version: 1, Pages: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	if err := writeFileAtomic(h.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	return nil
+}
+
+// Visit records that page was opened at
+func (h *History) Visit(page ManPage, at time.Time) error {
+	if h == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+	return h.update(func() {
+		entry, ok := h.entries[key]
+		if !ok {
+			entry = &HistoryEntry{Name: page.Name, Section: page.Section}
+			h.entries[key] = entry
+		}
+		entry.Visits++
+		entry.Recent = append(entry.Recent, Visit{At: at})
+		sort.SliceStable(entry.Recent, func(i, j int) bool {
+			return entry.Recent[i].At.Before(entry.Recent[j].At)
+		})
+		if len(entry.Recent) > historySamples {
+			entry.Recent = entry.Recent[len(entry.Recent)-historySamples:]
+		}
+	})
+}
+
+// AddDwell records how long the visit to page started at lasted
+func (h *History) AddDwell(page ManPage, at time.Time, dwell time.Duration) error {
+	if h == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+	return h.update(func() {
+		entry, ok := h.entries[key]
+		if !ok {
+			// Forgotten meanwhile
+			return
+		}
+		entry.Dwell += dwell.Seconds()
+		for i := range entry.Recent {
+			if entry.Recent[i].At.Equal(at) {
+				entry.Recent[i].Dwell += dwell.Seconds()
+			}
+		}
+	})
+}
+
+// Entries returns the history, highest frecency first when byFrecency is
+// set and most recently visited first otherwise
+func (h *History) Entries(byFrecency bool) []HistoryEntry {
+	if h == nil {
+		return nil
+	}
+	now := time.Now()
+	entries := make([]HistoryEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if byFrecency {
+			fi, fj := entries[i].Frecency(now), entries[j].Frecency(now)
+			if fi != fj {
+				return fi > fj
+			}
+		}
+		return entries[i].LastVisit().After(entries[j].LastVisit())
+	})
+	return entries
+}
+
+// Frecency returns the score of name(section), 0 if it was never opened
+func (h *History) Frecency(name, section string) float64 {
+	if h == nil {
+		return 0
+	}
+	entry, ok := h.entries[fmt.Sprintf("%s(%s)", name, section)]
+	if !ok {
+		return 0
+	}
+	return entry.Frecency(time.Now())
+}
+
+// Boosts returns the deep search score multiplier of every page in the
+// history, keyed by "name(section)"
+func (h *History) Boosts() map[string]float64 {
+	if h == nil || len(h.entries) == 0 {
+		return nil
+	}
+	now := time.Now()
+	boosts := make(map[string]float64, len(h.entries))
+	for key, entry := range h.entries {
+		boosts[key] = 1 + frecencyBoostWeight*frecencyBoost(entry.Frecency(now))
+	}
+	return boosts
+}
+
+// BoostFuzzy raises the filter scores of frecently read pages and re-sorts
+// results, so that of two similar matches the one usually read comes first
+func (h *History) BoostFuzzy(results []FuzzyResult) {
+	if h == nil || len(h.entries) == 0 {
+		return
+	}
+	for i := range results {
+		f := h.Frecency(results[i].Page.Name, results[i].Page.Section)
+		results[i].Score += int(fuzzyFrecencyBonus * frecencyBoost(f))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
+
+// Remove forgets name(section)
+func (h *History) Remove(name, section string) error {
+	if h == nil {
+		return nil
+	}
+	return h.update(func() {
+		delete(h.entries, fmt.Sprintf("%s(%s)", name, section))
+	})
+}
+
+// Clear forgets every page
+func (h *History) Clear() error {
+	if h == nil {
+		return nil
+	}
+	return h.update(func() {
+		h.entries = make(map[string]*HistoryEntry)
+	})
+}
+
+// formatAge describes how long ago t was, e.g. "3h ago"
+func formatAge(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openHistory shows the history view over the current one
+func (m *Model) openHistory() {
+	m.historyReturn = m.mode
+	m.mode = historyView
+	m.historyCursor = 0
+	m.confirmClear = false
+}
+
+// updateHistoryView handles keys while the history view is open
+func (m Model) updateHistoryView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	entries := m.history.Entries(m.historyByFrecency)
+	confirming := m.confirmClear
+	m.confirmClear = false
+
+	switch msg.String() {
+	case "ctrl+c", "q", "esc", "H":
+		m.mode = m.historyReturn
+		// The recent section may have changed
+		if m.mode == listView && m.fuzzyMatches == nil && m.initialQuery == "" {
+			return m, m.refilter()
+		}
+
+	case "up", "k":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+
+	case "down", "j":
+		if m.historyCursor < len(entries)-1 {
+			m.historyCursor++
+		}
+
+	case "s":
+		m.historyByFrecency = !m.historyByFrecency
+		m.historyCursor = 0
+
+	case "enter":
+		if m.historyCursor < len(entries) {
+			entry := entries[m.historyCursor]
+			return m, m.openPage(ManPage{Name: entry.Name, Section: entry.Section})
+		}
+
+	case "d", "x":
+		if m.historyCursor < len(entries) {
+			entry := entries[m.historyCursor]
+			if err := m.history.Remove(entry.Name, entry.Section); err != nil {
+				m.status = err.Error()
+			}
+			m.historyCursor = min(m.historyCursor, max(len(entries)-2, 0))
+		}
+
+	case "D":
+		if !confirming {
+			m.confirmClear = true
+			m.status = "Press D again to clear the whole history"
+			break
+		}
+		if err := m.history.Clear(); err != nil {
+			m.status = err.Error()
+		} else {
+			m.status = "History cleared"
+		}
+		m.historyCursor = 0
+	}
+
+	return m, nil
+}
+
+// renderHistoryView renders the pages read, most recent or most frecent first
+func (m Model) renderHistoryView() string {
+	var b strings.Builder
+
+	order := "most recent first"
+	if m.historyByFrecency {
+		order = "most read first"
+	}
+	b.WriteString(titleStyle.Render(" History "))
+	b.WriteString(statusStyle.Render("  " + order))
+	b.WriteString("\n\n")
+
+	if m.history == nil {
+		b.WriteString(statusStyle.Render(`  History is off. Set "history": true in config.json to record the pages you read.`))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("esc back"))
+		return b.String()
+	}
+
+	if m.err != nil {
+		b.WriteString(renderError(m.err))
+		b.WriteString("\n\n")
+	}
+
+	entries := m.history.Entries(m.historyByFrecency)
+	if len(entries) == 0 {
+		b.WriteString(statusStyle.Render("  No pages read yet."))
+		b.WriteString("\n")
+	}
+
+	// Keep the cursor in a window of 20 rows
+	now := time.Now()
+	start := max(m.historyCursor-10, 0)
+	end := min(start+20, len(entries))
+	for i := start; i < end; i++ {
+		entry := entries[i]
+		name := fmt.Sprintf("%s(%s)", entry.Name, entry.Section)
+		dwell := time.Duration(entry.Dwell * float64(time.Second)).Round(time.Second)
+		line := fmt.Sprintf("%-30s %-9s %3d visits  %8s read  score %.0f",
+			name, formatAge(entry.LastVisit()), entry.Visits, dwell, entry.Frecency(now))
+		if i == m.historyCursor {
+			b.WriteString(selectedItemStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(itemStyle.Render(line))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.status != "" {
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render("↑/k ↓/j select • enter open • s sort by recency/frecency • d forget • D clear all • esc back"))
+
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxFilterContext is the most lines of context the line filter shows around
+// each match
+const maxFilterContext = 20
+
+// lineFilter is the detail view's less-style & filter, showing only the lines
+// of the page that match a pattern, or the lines of one section
+type lineFilter struct {
+	active   bool
+	query    string
+	pattern  *regexp.Regexp // nil to keep every line of the section
+	section  string         // heading of the section to keep; "" for the whole page
+	context  int            // lines shown before and after each match
+	lines    []int          // page lines shown; -1 between runs that aren't adjacent
+	cursor   int            // index in lines
+	offset   int            // first index in lines on screen
+	returnTo Bookmark       // where the page was when the filter was opened
+}
+
+// filterLines returns the page lines a filter keeps: those matching pattern
+// (or every line when it's nil) with context lines around them, within the
+// section under heading unless it's "". Runs of lines that aren't adjacent
+// are separated by -1.
+func filterLines(lines []string, pattern *regexp.Regexp, heading string, context int) []int {
+	start, end := 0, len(lines)
+	if heading != "" {
+		start, end = sectionRange(lines, heading)
+	}
+
+	keep := make([]bool, end-start)
+	for i := start; i < end; i++ {
+		if pattern != nil && !pattern.MatchString(lines[i]) {
+			continue
+		}
+		for j := max(i-context, start); j <= min(i+context, end-1); j++ {
+			keep[j-start] = true
+		}
+	}
+
+	var shown []int
+	for i, kept := range keep {
+		if !kept {
+			continue
+		}
+		line := start + i
+		if n := len(shown); n > 0 && shown[n-1] != line-1 {
+			shown = append(shown, -1)
+		}
+		shown = append(shown, line)
+	}
+	return shown
+}
+
+// sectionRange returns the lines [start, end) of the first section under
+// heading, or an empty range if the page has none
+func sectionRange(lines []string, heading string) (int, int) {
+	for start, line := range lines {
+		if !isSectionHeading(line) || strings.TrimSpace(line) != heading {
+			continue
+		}
+		end := start + 1
+		for end < len(lines) && !isSectionHeading(lines[end]) {
+			end++
+		}
+		return start, end
+	}
+	return 0, 0
+}
+
+// sectionHeading returns the heading of the section line is in, or "" before
+// the first heading
+func sectionHeading(lines []string, line int) string {
+	for i := min(line, len(lines)-1); i >= 0; i-- {
+		if isSectionHeading(lines[i]) {
+			return strings.TrimSpace(lines[i])
+		}
+	}
+	return ""
+}
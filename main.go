@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -16,21 +22,269 @@ func main() {
 		return
 	}
 
-	// Check for command-line arguments
+	// Related pages from the search index
+	if len(os.Args) > 2 && os.Args[1] == "--related" {
+		handleRelated(os.Args[2:])
+		return
+	}
+
+	// Manage the on-disk render cache
+	if len(os.Args) > 2 && os.Args[1] == "--cache" {
+		handleCache(os.Args[2:])
+		return
+	}
+
+	// Bookmark lists and reading-list import/export
+	if len(os.Args) > 2 && os.Args[1] == "--bookmarks" {
+		handleBookmarks(os.Args[2:])
+		return
+	}
+
+	// Pages read, ranked by frecency
+	if len(os.Args) > 2 && os.Args[1] == "--history" {
+		handleHistory(os.Args[2:])
+		return
+	}
+
+	// Page snapshots and what changed since them
+	if len(os.Args) > 2 && os.Args[1] == "--snapshot" {
+		handleSnapshot(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "--diff" {
+		handleDiff(os.Args[2:])
+		return
+	}
+
+	// Shell widgets looking up the command being typed
+	if len(os.Args) > 2 && os.Args[1] == "--shell-init" {
+		handleShellInit(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--widget" {
+		handleWidget(os.Args[2:])
+		return
+	}
+
+	// Choose an example command from a page and print it
+	if len(os.Args) > 1 && os.Args[1] == "--pick-example" {
+		handlePickExample(os.Args[2:])
+		return
+	}
+
+	// Any other arguments are a search; commands are flags so they never
+	// take over one, e.g. "lazyman diff 1"
 	var initialQuery string
 	if len(os.Args) > 1 {
 		initialQuery = strings.Join(os.Args[1:], " ")
 	}
 
-	p := tea.NewProgram(InitialModel(initialQuery), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	model := newTUIModel(initialQuery)
+
+	if err := runTUI(model); err != nil {
 		fmt.Printf("Error running lazyman: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// newTUIModel creates the TUI's model with the user's settings applied
+func newTUIModel(query string) Model {
+	model := InitialModel(query)
+	model.applyConfig(loadConfigOrWarn())
+	return model
+}
+
+// runTUI runs the TUI until it quits, then saves the open tabs for the next
+// launch
+func runTUI(model Model) error {
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if model.config.Mouse {
+		// Cell motion rather than all motion, as dragging is all that needs it
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	final, err := tea.NewProgram(model, options...).Run()
+	if err != nil {
+		return err
+	}
+	if m, ok := final.(Model); ok {
+		if err := m.saveSession(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		// An example chosen to print on quitting
+		if m.picked != "" {
+			fmt.Println(m.picked)
+		}
+	}
+	return nil
+}
+
+// handlePickExample runs "lazyman --pick-example <page>": the TUI lists the
+// page's examples on stderr and the one picked is printed to stdout, so it
+// can be used as $(lazyman --pick-example tar)
+func handlePickExample(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: lazyman --pick-example [section] <page>")
+		os.Exit(2)
+	}
+	name, section := parsePageArgs(args)
+	if section == "" {
+		section = resolveSection(name)
+	}
+	if section == "" {
+		fmt.Fprintf(os.Stderr, "Error: no man page named %q\n", name)
+		os.Exit(1)
+	}
+
+	model := newTUIModel("")
+	model.pickExample = true
+	model.startPage = ManPage{Name: name, Section: section}
+	model.loading = false
+
+	// Draw on stderr, keeping stdout for the example
+	lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(os.Stderr))
+	final, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(os.Stderr)).Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running lazyman: %v\n", err)
+		os.Exit(1)
+	}
+	m, ok := final.(Model)
+	switch {
+	case !ok:
+		os.Exit(1)
+	case m.err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", m.err)
+		os.Exit(1)
+	case m.picked == "" && len(m.examples) == 0:
+		fmt.Fprintf(os.Stderr, "No examples found in %s(%s)\n", name, section)
+		os.Exit(1)
+	case m.picked == "":
+		// Quit without picking
+		os.Exit(1)
+	}
+	fmt.Println(m.picked)
+}
+
+// handleShellInit runs "lazyman --shell-init bash|zsh|fish", printing the
+// script that binds the widget
+func handleShellInit(args []string) {
+	script, err := shellInitScript(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: lazyman --shell-init bash|zsh|fish")
+		os.Exit(2)
+	}
+	fmt.Print(script)
+}
+
+// handleWidget runs lazyman for a shell widget: the page for the command on
+// the line is shown below the prompt, at the flag under the cursor, and the
+// command line with the option or example picked is printed to stdout as
+// the cursor position, a newline and the line
+func handleWidget(args []string) {
+	flags := flag.NewFlagSet("lazyman --widget", flag.ExitOnError)
+	line := flags.String("line", "", "the command line being typed")
+	point := flags.Int("point", -1, "the cursor position in the line, in characters (default the end)")
+	flags.Parse(args)
+
+	widget := newShellWidget(*line, *point)
+	pages, err := GetManPages()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	var page ManPage
+	for _, name := range widget.pages {
+		for _, p := range pages {
+			if p.Name == name && (page.Name == "" || p.Section < page.Section) {
+				page = p
+			}
+		}
+		if page.Name != "" {
+			break
+		}
+	}
+	if page.Name == "" {
+		if len(widget.pages) == 0 {
+			fmt.Fprintln(os.Stderr, "lazyman: no command on the line")
+		} else {
+			fmt.Fprintf(os.Stderr, "lazyman: no man page for %s\n", widget.pages[len(widget.pages)-1])
+		}
+		os.Exit(1)
+	}
+
+	model := newTUIModel("")
+	model.widget = &widget
+	model.startPage = ManPage{Name: page.Name, Section: page.Section}
+	model.loading = false
+
+	// Draw on stderr, keeping stdout for the command line
+	lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(os.Stderr))
+	final, err := tea.NewProgram(model, tea.WithOutput(os.Stderr)).Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running lazyman: %v\n", err)
+		os.Exit(1)
+	}
+	m, ok := final.(Model)
+	switch {
+	case !ok:
+		os.Exit(1)
+	case m.err != nil:
+		fmt.Fprintf(os.Stderr, "lazyman: %v\n", m.err)
+		os.Exit(1)
+	case m.picked == "":
+		os.Exit(1)
+	}
+	fmt.Printf("%d\n%s\n", m.pickedPoint, m.picked)
+}
+
+// loadConfigOrWarn loads the config file, warning on stderr if it's invalid
+func loadConfigOrWarn() Config {
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using defaults\n", err)
+	}
+	return cfg
+}
+
 // handleSearchIndex handles the -S flag for indexing and searching
 func handleSearchIndex(args []string) {
+	flags := flag.NewFlagSet("lazyman -S", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print results as JSON instead of opening the TUI")
+	limit := flags.Int("limit", defaultPageSize, "number of results to print with --json")
+	offset := flags.Int("offset", 0, "number of results to skip with --json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lazyman -S [--json [--limit N] [--offset N]] [--] [query]")
+		fmt.Fprintln(flags.Output())
+		fmt.Fprintln(flags.Output(), QuerySyntaxHelp)
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	// Only the known flags at the front are lazyman's: the rest is the query,
+	// where -term excludes a term
+	front := 0
+	for front < len(args) && strings.HasPrefix(args[front], "-") {
+		if args[front] == "--" {
+			front++
+			break
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[front], "-"), "=")
+		if flags.Lookup(name) == nil && name != "h" && name != "help" {
+			break
+		}
+		front++
+		if (name == "limit" || name == "offset") && !hasValue {
+			front++ // its value
+		}
+	}
+	front = min(front, len(args))
+	flags.Parse(args[:front])
+	args = append(flags.Args(), args[front:]...)
+
+	if *jsonOutput {
+		printSearchJSON(strings.Join(args, " "), *limit, *offset)
+		return
+	}
+
 	betaStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("208")).
 		Bold(true)
@@ -54,6 +308,7 @@ func handleSearchIndex(args []string) {
 		indexPath, _ := GetIndexPath()
 		fmt.Printf("\n✓ Index stored at: %s\n", indexPath)
 		fmt.Println("\nYou can now search with: lazyman -S <query>")
+		fmt.Println("Query syntax: lazyman -S --help")
 		return
 	}
 
@@ -66,54 +321,379 @@ func handleSearchIndex(args []string) {
 		os.Exit(1)
 	}
 
-	// Perform search
-	results, err := SearchIndexedManPages(query)
+	// The TUI runs the search itself so query errors are shown in place
+	model := newTUIModel(query)
+	model.deepSearch = true
+	model.searchInput.SetValue(query)
+
+	err := runTUI(model)
+	CloseSearchIndex()
+	if err != nil {
+		fmt.Printf("Error running lazyman: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// handleRelated prints pages related to the given page
+func handleRelated(args []string) {
+	name, section := parsePageArgs(args)
+	if section == "" {
+		section = resolveSection(name)
+	}
+	if section == "" {
+		fmt.Printf("Error: no man page named %q\n", name)
+		os.Exit(1)
+	}
+
+	related, err := FindRelatedPages(name, section, 15)
+	CloseSearchIndex()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(related) == 0 {
+		fmt.Printf("No pages related to %s(%s) found\n", name, section)
+		return
+	}
+	for _, r := range related {
+		line := fmt.Sprintf("%s(%s)", r.ManPage.Name, r.ManPage.Section)
+		if r.ManPage.Description != "" {
+			line += " - " + r.ManPage.Description
+		}
+		fmt.Printf("%-60s [%s]\n", line, strings.Join(r.Reasons, ", "))
+	}
+}
+
+// handleCache runs "lazyman --cache clear" or "lazyman --cache stats"
+func handleCache(args []string) {
+	switch args[0] {
+	case "clear":
+		if err := renderDiskCache.Clear(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Render cache cleared")
+	case "stats":
+		stats := renderDiskCache.Stats()
+		if stats.Dir == "" {
+			fmt.Println("No cache directory available")
+			return
+		}
+		fmt.Printf("Location: %s\n", stats.Dir)
+		fmt.Printf("Entries:  %d\n", stats.Entries)
+		fmt.Printf("Size:     %s (limit %s)\n", formatBytes(stats.Bytes), formatBytes(diskCacheMaxBytes))
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: lazyman --cache clear|stats")
+		os.Exit(2)
+	}
+}
+
+// handleBookmarks runs "lazyman --bookmarks list|export|import"
+func handleBookmarks(args []string) {
+	usage := "Usage: lazyman --bookmarks list|export [--tag T] [file] | import [--tag T] file|-"
+	flags := flag.NewFlagSet("lazyman --bookmarks "+args[0], flag.ExitOnError)
+	tag := flags.String("tag", "", "only bookmarks in this collection; on import, the collection to add them to")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+
+	bookmarks, err := LoadBookmarks()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		for _, bookmark := range bookmarks.List(*tag) {
+			if len(bookmark.Tags) > 0 {
+				fmt.Printf("%-50s [%s]\n", bookmark.String(), strings.Join(bookmark.Tags, ", "))
+			} else {
+				fmt.Println(bookmark.String())
+			}
+		}
+	case "export":
+		var out bytes.Buffer
+		if err := bookmarks.Export(&out, *tag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if flags.NArg() == 0 {
+			os.Stdout.Write(out.Bytes())
+			return
+		}
+		if err := os.WriteFile(flags.Arg(0), out.Bytes(), 0o644); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "import":
+		if flags.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		in := os.Stdin
+		if flags.Arg(0) != "-" {
+			file, err := os.Open(flags.Arg(0))
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			in = file
+		}
+		added, err := bookmarks.Import(in, strings.ToLower(strings.TrimSpace(*tag)))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %d new bookmarks\n", added)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// handleHistory runs "lazyman --history list" or "lazyman --history clear"
+func handleHistory(args []string) {
+	history, err := LoadHistory()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		now := time.Now()
+		for _, entry := range history.Entries(true) {
+			fmt.Printf("%-30s %6.0f  %3d visits, last %s\n",
+				fmt.Sprintf("%s(%s)", entry.Name, entry.Section), entry.Frecency(now), entry.Visits, formatAge(entry.LastVisit()))
+		}
+	case "clear":
+		if err := history.Clear(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("History cleared")
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: lazyman --history list|clear")
+		os.Exit(2)
+	}
+}
+
+// handleSnapshot runs "lazyman --snapshot --all" or "lazyman --snapshot <page>"
+func handleSnapshot(args []string) {
+	snapshots, err := LoadSnapshots()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "--all" {
+		started := time.Now()
+		pages, err := GetManPages()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		recorded := 0
+		for _, page := range pages {
+			if added, err := snapshots.Record(page, page.Path); err == nil && added {
+				recorded++
+			}
+		}
+		if err := snapshots.Save(started); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Snapshotted %d of %d pages; the rest are unchanged\n", recorded, len(pages))
+		return
+	}
+
+	name, section := parsePageArgs(args)
+	if section == "" {
+		section = resolveSection(name)
+	}
+	path := findManPagePath(name, section)
+	if section == "" || path == "" {
+		fmt.Printf("Error: no man page named %q\n", name)
+		os.Exit(1)
+	}
+	added, err := snapshots.Record(ManPage{Name: name, Section: section}, path)
+	if err == nil {
+		err = snapshots.Save(time.Time{})
+	}
 	if err != nil {
-		fmt.Printf("Error performing search: %v\n", err)
-		fmt.Printf("\nDebug info:\n")
-		fmt.Printf("  Query: %s\n", query)
-		fmt.Printf("  Index path: %s\n", indexPath)
-		if absPath, err := GetIndexPath(); err == nil {
-			fmt.Printf("  Absolute path: %s\n", absPath)
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if added {
+		fmt.Printf("Snapshotted %s(%s)\n", name, section)
+	} else {
+		fmt.Printf("%s(%s) is unchanged since its last snapshot\n", name, section)
+	}
+}
+
+// handleDiff prints how a page changed since a snapshot of it
+func handleDiff(args []string) {
+	flags := flag.NewFlagSet("lazyman --diff", flag.ExitOnError)
+	sinceFlag := flags.String("since", "", "compare with the page as it was on this date (YYYY-MM-DD)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lazyman --diff <page> [--since date]")
+		flags.PrintDefaults()
+	}
+	// Flags may come before or after the page
+	var pageArgs []string
+	for len(args) > 0 {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			break
 		}
-		fmt.Printf("\nTry rebuilding the index with: lazyman -S\n")
+		pageArgs = append(pageArgs, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(pageArgs) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		if since, err = parseSinceDate(*sinceFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	name, section := parsePageArgs(pageArgs)
+	if section == "" {
+		section = resolveSection(name)
+	}
+	if section == "" {
+		fmt.Printf("Error: no man page named %q\n", name)
+		os.Exit(1)
+	}
+
+	snapshots, err := LoadSnapshots()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	diff, err := DiffInstalledPage(snapshots, ManPage{Name: name, Section: section}, since)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, line := range diff.Format() {
+		fmt.Println(line)
+	}
+}
 
-	// Convert search results to ManPages and store matches
-	pages := make([]ManPage, 0, len(results))
-	matchesMap := make(map[string][]string)
+// formatBytes formats a byte count for people, e.g. "12.3 MiB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
 
-	for _, result := range results {
-		pages = append(pages, result.ManPage)
-		// Store matches for this page
-		key := fmt.Sprintf("%s(%s)", result.ManPage.Name, result.ManPage.Section)
-		matchesMap[key] = result.Matches
+// parsePageArgs accepts a page as "epoll(7)", "7 epoll" or "epoll"
+func parsePageArgs(args []string) (string, string) {
+	if len(args) >= 2 && args[0] != "" && args[0][0] >= '0' && args[0][0] <= '9' {
+		return args[1], args[0]
+	}
+	return parseDocID(strings.Join(args, " "))
+}
 
-		// Debug: print stored matches
-		if len(result.Matches) > 0 {
-			fmt.Printf("DEBUG: Stored %d matches for %s\n", len(result.Matches), key)
+// resolveSection returns the first section the named page is installed in
+func resolveSection(name string) string {
+	pages, err := GetManPages()
+	if err != nil {
+		return ""
+	}
+	section := ""
+	for _, page := range pages {
+		if page.Name == name && (section == "" || page.Section < section) {
+			section = page.Section
 		}
 	}
+	return section
+}
 
-	fmt.Printf("DEBUG: Total pages with matches: %d/%d\n", len(matchesMap), len(pages))
+// searchJSON is the --json output of a deep search
+type searchJSON struct {
+	Query   string             `json:"query"`
+	Total   uint64             `json:"total"`
+	Offset  int                `json:"offset"`
+	Results []searchResultJSON `json:"results"`
+}
 
-	// Launch existing TUI with search results
-	model := InitialModel("")
-	model.manPages = pages
-	model.filteredPages = pages
-	model.initialQuery = query
-	model.searchInput.SetValue(query)
-	model.searchResultMatches = matchesMap
+type searchResultJSON struct {
+	Name        string   `json:"name"`
+	Section     string   `json:"section"`
+	Description string   `json:"description,omitempty"`
+	Via         string   `json:"via,omitempty"`
+	Score       float64  `json:"score"`
+	TotalHits   int      `json:"total_hits"`
+	Terms       []string `json:"terms,omitempty"`
+	Snippets    []string `json:"snippets,omitempty"`
+}
 
-	// If no results, show modal
-	if len(pages) == 0 {
-		model.noMatchSuggestions = model.findFuzzySuggestions(query, model.manPages)
+// printSearchJSON runs a deep search and prints one page of results as JSON
+func printSearchJSON(query string, limit, offset int) {
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "Error: --json needs a query")
+		os.Exit(2)
 	}
 
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running lazyman: %v\n", err)
+	response, err := SearchIndexedManPages(context.Background(), query, SearchOptions{From: offset, Size: limit})
+	CloseSearchIndex()
+	if err != nil {
+		var queryErr *QueryError
+		if errors.As(err, &queryErr) {
+			fmt.Fprintln(os.Stderr, queryErr.Pretty())
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	out := searchJSON{
+		Query:   query,
+		Total:   response.Total,
+		Offset:  offset,
+		Results: make([]searchResultJSON, 0, len(response.Results)),
+	}
+	for _, result := range response.Results {
+		snippets := make([]string, 0, len(result.Snippets))
+		for _, snippet := range result.Snippets {
+			snippets = append(snippets, snippet.Text)
+		}
+		out.Results = append(out.Results, searchResultJSON{
+			Name:        result.ManPage.Name,
+			Section:     result.ManPage.Section,
+			Description: result.ManPage.Description,
+			Via:         result.ManPage.Via,
+			Score:       result.Score,
+			TotalHits:   result.TotalHits,
+			Terms:       result.Terms,
+			Snippets:    snippets,
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -18,6 +19,7 @@ type ManPage struct {
 	Section     string
 	Description string
 	Path        string
+	Via         string // task phrase whose synonym matched this page, if any
 }
 
 // GetManPages retrieves all available man pages on the system
@@ -81,14 +83,21 @@ func GetManPages() ([]ManPage, error) {
 	return result, nil
 }
 
-// GetManContent retrieves the formatted content of a man page
-func GetManContent(name, section string) (string, error) {
+// GetManContent retrieves the formatted content of a man page, laid out for
+// width columns when width is positive. Bold and underlining are kept as
+// overstrike or SGR sequences; see ParseManFormatting.
+func GetManContent(name, section string, width int) (string, error) {
 	var cmd *exec.Cmd
 	if section != "" {
 		cmd = exec.Command("man", section, name)
 	} else {
 		cmd = exec.Command("man", name)
 	}
+	// man strips formatting when its output isn't a terminal unless asked
+	cmd.Env = append(os.Environ(), "MAN_KEEP_FORMATTING=1")
+	if width > 0 {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("MANWIDTH=%d", width), fmt.Sprintf("COLUMNS=%d", width))
+	}
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -98,22 +107,57 @@ func GetManContent(name, section string) (string, error) {
 	return string(output), nil
 }
 
-// SearchManPages searches for man pages by keyword
-func SearchManPages(query string) ([]ManPage, error) {
+// SearchManPages searches for man pages by keyword; cancelling ctx kills the
+// man processes
+func SearchManPages(ctx context.Context, query string) ([]ManPage, error) {
 	// If query is "." or empty, return all pages
 	if query == "." || query == "" {
 		return GetManPages()
 	}
 
-	cmd := exec.Command("man", "-k", query)
+	cmd := exec.CommandContext(ctx, "man", "-k", query)
 	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		// man -k returns exit status 1 when no results found, which is not really an error
-		// Just return empty list
-		return []ManPage{}, nil
+		// Just keep going with the synonym matches
+		output = nil
 	}
 
-	lines := strings.Split(string(output), "\n")
+	pages := parseWhatisOutput(string(output))
+
+	// Add pages for task-phrase synonyms, e.g. "compress a directory" -> tar
+	seen := make(map[string]bool)
+	for _, page := range pages {
+		seen[fmt.Sprintf("%s(%s)", page.Name, page.Section)] = true
+	}
+	for _, expansion := range ExpandQuery(query) {
+		output, err := exec.CommandContext(ctx, "man", "-f", expansion.Term).Output()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+		for _, page := range parseWhatisOutput(string(output)) {
+			key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			page.Via = expansion.Phrase
+			pages = append(pages, page)
+		}
+	}
+
+	return pages, nil
+}
+
+// parseWhatisOutput parses "name (section) - description" lines from man -k and man -f
+func parseWhatisOutput(output string) []ManPage {
+	lines := strings.Split(output, "\n")
 	pages := make([]ManPage, 0)
 
 	for _, line := range lines {
@@ -143,7 +187,7 @@ func SearchManPages(query string) ([]ManPage, error) {
 		}
 	}
 
-	return pages, nil
+	return pages
 }
 
 // getManPaths returns common man page directories
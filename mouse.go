@@ -0,0 +1,179 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// defaultSplitRatio is the share of the width the list takes beside the
+	// preview until the divider is dragged
+	defaultSplitRatio = 0.6
+	// minSplitRatio and maxSplitRatio keep both panes usable while dragging
+	minSplitRatio = 0.2
+	maxSplitRatio = 0.8
+	// minListWidth and minPreviewWidth are the fewest columns the list and
+	// preview get, the list winning on a terminal too narrow for both
+	minListWidth    = 30
+	minPreviewWidth = 20
+	// doubleClickTime is the most time between two clicks on a page for them
+	// to open it
+	doubleClickTime = 400 * time.Millisecond
+	// filterBarRow is the row of the list view the section filters are on
+	filterBarRow = 2
+	// detailContentRow is the first row of the page in the detail view, below
+	// the title
+	detailContentRow = 2
+)
+
+// updateMouse handles the mouse in the list, preview and detail views
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	switch m.mode {
+	case listView:
+		return m.updateListMouse(msg)
+	case detailView:
+		return m.updateDetailMouse(msg)
+	}
+	return m, nil
+}
+
+// updateListMouse scrolls the list or preview under the pointer, selects and
+// opens pages, toggles section filters, and drags the divider
+func (m Model) updateListMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	listWidth := m.listWidth()
+
+	if m.dragging {
+		switch msg.Action {
+		case tea.MouseActionMotion:
+			ratio := float64(msg.X) / float64(max(m.width, 1))
+			m.splitRatio = max(min(ratio, maxSplitRatio), minSplitRatio)
+			m.resizePreview()
+		case tea.MouseActionRelease:
+			m.dragging = false
+			// Render the preview again at its new width
+			return m, m.reflowPreview()
+		}
+		return m, nil
+	}
+
+	if tea.MouseEvent(msg).IsWheel() {
+		if msg.X > listWidth {
+			var cmd tea.Cmd
+			m.previewPort, cmd = m.previewPort.Update(msg)
+			return m, cmd
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			if m.cursor > 0 {
+				m.cursor--
+				return m, m.previewSelected()
+			}
+		case tea.MouseButtonWheelDown:
+			if m.cursor < len(m.listedPages())-1 {
+				m.cursor++
+				return m, tea.Batch(m.loadMoreResults(), m.previewSelected())
+			}
+		}
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+
+	// The divider is drawn as " │ " after the list
+	if msg.X >= listWidth && msg.X <= listWidth+2 {
+		m.dragging = true
+		return m, nil
+	}
+	if msg.X > listWidth {
+		return m, nil
+	}
+
+	if msg.Y == filterBarRow {
+		if section := m.sectionAt(msg.X); section != "" {
+			return m, m.toggleSection(section)
+		}
+		return m, nil
+	}
+
+	_, pageRows := m.renderListPanel(listWidth)
+	index, ok := pageRows[msg.Y]
+	if !ok {
+		return m, nil
+	}
+	now := time.Now()
+	double := index == m.lastClickRow && now.Sub(m.lastClick) < doubleClickTime
+	m.lastClick, m.lastClickRow = now, index
+	if double {
+		m.lastClick = time.Time{}
+		return m, m.openPage(m.listedPages()[index])
+	}
+	if index == m.cursor {
+		return m, nil
+	}
+	m.cursor = index
+	return m, tea.Batch(m.loadMoreResults(), m.previewSelected())
+}
+
+// listedPages returns the pages the list shows: the filtered pages, or the
+// suggestions when nothing matched
+func (m Model) listedPages() []ManPage {
+	if len(m.filteredPages) == 0 && len(m.noMatchSuggestions) > 0 {
+		return m.noMatchSuggestions
+	}
+	return m.filteredPages
+}
+
+// sectionAt returns the section whose filter is at column x of the filter
+// bar, or ""
+func (m Model) sectionAt(x int) string {
+	col := lipgloss.Width("  Sections: ")
+	for _, filter := range m.sectionFilters {
+		width := lipgloss.Width(m.sectionLabel(filter))
+		if x >= col && x < col+width {
+			return filter.Section
+		}
+		col += width + 1
+	}
+	return ""
+}
+
+// updateDetailMouse scrolls the page with the wheel and follows
+// cross-references such as "gzip(1)" when they're clicked
+func (m Model) updateDetailMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if tea.MouseEvent(msg).IsWheel() {
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+
+	row := msg.Y - detailContentRow
+	if row < 0 || row >= m.viewport.Height {
+		return m, nil
+	}
+	row += m.viewport.YOffset
+	if row >= len(m.displayMap) || m.displayMap[row] < 0 {
+		return m, nil
+	}
+	line := m.currentLines[m.displayMap[row]].Text
+	col := msg.X
+	if len(m.pageNotes) > 0 {
+		// Past the note gutter
+		col -= 2
+	}
+
+	for _, match := range manReferencePattern.FindAllStringSubmatchIndex(line, -1) {
+		start, end := lipgloss.Width(line[:match[0]]), lipgloss.Width(line[:match[1]])
+		if col >= start && col < end {
+			page := ManPage{Name: line[match[2]:match[3]], Section: line[match[4]:match[5]]}
+			return m, m.openPage(page)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// minAnchorSimilarity is how alike a line must be to a note's quoted line for
+// the note to move onto it once the page has changed
+const minAnchorSimilarity = 0.6
+
+// Note is an annotation on a man page. It is anchored by the section heading
+// it was written under, its distance below that heading and the text of the
+// line it was written on, so it can find its line again after the page
+// changes.
+type Note struct {
+	Heading string // section heading, or "" for the top of the page
+	Line    int    // lines below Heading when the note was written
+	Quote   string // the annotated line, with spacing collapsed
+	Body    string
+}
+
+// String describes where the note is anchored, e.g. "OPTIONS +12"
+func (n Note) String() string {
+	anchor := n.Heading
+	if anchor == "" {
+		anchor = "top"
+	}
+	if n.Line > 0 {
+		anchor += fmt.Sprintf(" +%d", n.Line)
+	}
+	return anchor
+}
+
+// NoteStore holds the notes on every page. Each page's notes are a plain
+// text file, <name>.<section>.md, so a directory of notes can be kept in git.
+type NoteStore struct {
+	dir   string
+	pages map[string][]Note // keyed by "name(section)"
+}
+
+// notesDir returns where notes are kept: configured, or "notes" in the data
+// directory
+func notesDir(cfg Config) (string, error) {
+	if cfg.NotesDir != "" {
+		if strings.HasPrefix(cfg.NotesDir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(home, cfg.NotesDir[2:]), nil
+		}
+		return cfg.NotesDir, nil
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "notes"), nil
+}
+
+// LoadNotes reads every note file in dir; a missing directory has no notes
+func LoadNotes(dir string) (*NoteStore, error) {
+	store := &NoteStore{dir: dir, pages: make(map[string][]Note)}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	for _, entry := range entries {
+		name, section, ok := noteFilePage(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return store, fmt.Errorf("failed to read notes: %w", err)
+		}
+		if notes := parseNotes(string(data)); len(notes) > 0 {
+			store.pages[fmt.Sprintf("%s(%s)", name, section)] = notes
+		}
+	}
+	return store, nil
+}
+
+// noteFilePage returns the page a note file belongs to, from its name
+func noteFilePage(filename string) (string, string, bool) {
+	base, ok := strings.CutSuffix(filename, ".md")
+	if !ok {
+		return "", "", false
+	}
+	idx := strings.LastIndex(base, ".")
+	if idx <= 0 || idx == len(base)-1 {
+		return "", "", false
+	}
+	return base[:idx], base[idx+1:], true
+}
+
+// Path returns the file holding the notes on name(section)
+func (s *NoteStore) Path(name, section string) string {
+	return filepath.Join(s.dir, name+"."+section+".md")
+}
+
+// Notes returns the notes on name(section)
+func (s *NoteStore) Notes(name, section string) []Note {
+	return s.pages[fmt.Sprintf("%s(%s)", name, section)]
+}
+
+// Has reports whether name(section) has notes
+func (s *NoteStore) Has(name, section string) bool {
+	return len(s.Notes(name, section)) > 0
+}
+
+// Add appends a note to name(section) and saves its file
+func (s *NoteStore) Add(name, section string, note Note) error {
+	key := fmt.Sprintf("%s(%s)", name, section)
+	s.pages[key] = append(s.pages[key], note)
+	return s.save(name, section)
+}
+
+// Reload re-reads the notes on name(section), e.g. after editing its file
+func (s *NoteStore) Reload(name, section string) error {
+	key := fmt.Sprintf("%s(%s)", name, section)
+	data, err := os.ReadFile(s.Path(name, section))
+	if errors.Is(err, fs.ErrNotExist) {
+		delete(s.pages, key)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read notes: %w", err)
+	}
+	s.pages[key] = parseNotes(string(data))
+	return nil
+}
+
+// EnsureFile creates the note file for name(section) if it doesn't exist, so
+// it can be opened in an editor
+func (s *NoteStore) EnsureFile(name, section string) (string, error) {
+	if s.dir == "" {
+		return "", errors.New("notes can't be saved: no notes directory")
+	}
+	path := s.Path(name, section)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return path, s.save(name, section)
+}
+
+func (s *NoteStore) save(name, section string) error {
+	if s.dir == "" {
+		return errors.New("notes can't be saved: no notes directory")
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save notes: %w", err)
+	}
+	content := formatNotes(name, section, s.Notes(name, section))
+	if err := os.WriteFile(s.Path(name, section), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to save notes: %w", err)
+	}
+	return nil
+}
+
+// Search returns the "name(section)" of every page with a note containing
+// all the words of query, ignoring case
+func (s *NoteStore) Search(query string) map[string]bool {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil
+	}
+	found := make(map[string]bool)
+	for key, notes := range s.pages {
+		for _, note := range notes {
+			text := strings.ToLower(note.Body + "\n" + note.Quote)
+			all := true
+			for _, word := range words {
+				if !strings.Contains(text, word) {
+					all = false
+					break
+				}
+			}
+			if all {
+				found[key] = true
+				break
+			}
+		}
+	}
+	return found
+}
+
+// parseNotes reads the notes in a note file. Note files look like this, and
+// can be edited by hand:
+//
+//	# Notes on tar(1)
+//
+//	@@ OPTIONS +12
+//	> -P, --absolute-names
+//	Never on the backup hosts.
+//
+// "@@" starts a note and gives its heading and offset, "> " quotes the line
+// it is attached to, and everything up to the next "@@" is the note itself.
+// Lines before the first note are comments.
+func parseNotes(text string) []Note {
+	var notes []Note
+	var current *Note
+	var body []string
+
+	finish := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			notes = append(notes, *current)
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if anchor, ok := strings.CutPrefix(line, "@@"); ok {
+			finish()
+			current = &Note{}
+			current.Heading, current.Line = parseNoteAnchor(anchor)
+			continue
+		}
+		if current == nil {
+			// Comments and blank lines before the first note
+			continue
+		}
+		if quote, ok := strings.CutPrefix(line, "> "); ok && len(body) == 0 && current.Quote == "" {
+			current.Quote = collapseSpaces(quote)
+			continue
+		}
+		body = append(body, line)
+	}
+	finish()
+	return notes
+}
+
+// parseNoteAnchor splits "OPTIONS +12" into a heading and offset
+func parseNoteAnchor(anchor string) (string, int) {
+	anchor = strings.TrimSpace(anchor)
+	if idx := strings.LastIndex(anchor, "+"); idx >= 0 {
+		if n, err := strconv.Atoi(anchor[idx+1:]); err == nil {
+			return strings.TrimSpace(anchor[:idx]), n
+		}
+	}
+	return anchor, 0
+}
+
+// formatNotes writes notes in the note file format
+func formatNotes(name, section string, notes []Note) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Notes on %s(%s)\n", name, section)
+	for _, note := range notes {
+		b.WriteString("\n@@")
+		if note.Heading != "" {
+			b.WriteString(" " + note.Heading)
+		}
+		if note.Line > 0 || note.Heading == "" {
+			fmt.Fprintf(&b, " +%d", note.Line)
+		}
+		b.WriteString("\n")
+		if note.Quote != "" {
+			b.WriteString("> " + note.Quote + "\n")
+		}
+		if note.Body != "" {
+			b.WriteString(note.Body + "\n")
+		}
+	}
+	return b.String()
+}
+
+// collapseSpaces trims s and replaces runs of whitespace with one space, so
+// lines compare equal across renders at different widths and indents
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// anchorNote finds the line of lines a note belongs to. The quoted line is
+// looked for under the note's heading first, then anywhere, picking the
+// candidate nearest to where the note was written; failing an exact match
+// the most similar line is used. It reports false if the note could only be
+// placed by its heading and offset.
+func anchorNote(lines []string, note Note) (int, bool) {
+	start, end := 0, len(lines)
+	expected := note.Line
+	headingFound := note.Heading == ""
+	if !headingFound {
+		for i, line := range lines {
+			if isSectionHeading(line) && strings.TrimSpace(line) == note.Heading {
+				start, expected, headingFound = i, i+note.Line, true
+				break
+			}
+		}
+		if headingFound {
+			for end = start + 1; end < len(lines) && !isSectionHeading(lines[end]); end++ {
+			}
+		}
+	}
+	if note.Quote == "" {
+		return clampLine(expected, lines), headingFound
+	}
+
+	// An exact match under the heading, then anywhere on the page
+	for _, r := range [][2]int{{start, end}, {0, len(lines)}} {
+		best := -1
+		for i := r[0]; i < r[1]; i++ {
+			if collapseSpaces(lines[i]) == note.Quote && (best < 0 || abs(i-expected) < abs(best-expected)) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return best, true
+		}
+	}
+
+	// The line was reworded: take the most similar one
+	best, bestScore := -1, minAnchorSimilarity
+	for _, r := range [][2]int{{start, end}, {0, len(lines)}} {
+		for i := r[0]; i < r[1]; i++ {
+			if score := lineSimilarity(collapseSpaces(lines[i]), note.Quote); score > bestScore ||
+				(score == bestScore && best >= 0 && abs(i-expected) < abs(best-expected)) {
+				best, bestScore = i, score
+			}
+		}
+		if best >= 0 {
+			return best, true
+		}
+	}
+	return clampLine(expected, lines), false
+}
+
+// lineSimilarity scores two lines from 0 to 1 by edit distance
+func lineSimilarity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	// Lines of very different lengths can't be similar enough; skip the
+	// edit distance for them
+	if float64(min(len(a), len(b))) < float64(longest)*minAnchorSimilarity {
+		return 0
+	}
+	return 1 - float64(levenshteinDistance(a, b))/float64(longest)
+}
+
+func clampLine(line int, lines []string) int {
+	return max(min(line, len(lines)-1), 0)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	noteMarkStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("180")).
+			Bold(true)
+
+	noteStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("180"))
+)
+
+// placedNote is a note on the open page with the line it was anchored to
+type placedNote struct {
+	Note
+	line  int
+	exact bool // anchored by its quoted line rather than only its heading
+}
+
+// notesEditedMsg fires when the editor opened on a page's notes exits
+type notesEditedMsg struct {
+	page ManPage
+	err  error
+}
+
+// placeNotes anchors the open page's notes to its current lines
+func (m *Model) placeNotes() {
+	m.pageNotes = nil
+	notes := m.notes.Notes(m.currentPage.Name, m.currentPage.Section)
+	if len(notes) == 0 {
+		return
+	}
+	lines := strings.Split(m.currentContent, "\n")
+	for _, note := range notes {
+		line, exact := anchorNote(lines, note)
+		m.pageNotes = append(m.pageNotes, placedNote{Note: note, line: line, exact: exact})
+	}
+	sort.SliceStable(m.pageNotes, func(i, j int) bool {
+		return m.pageNotes[i].line < m.pageNotes[j].line
+	})
+}
+
+// layoutDetail fills the detail view with the open page. Pages with notes
+// get a margin marking the annotated lines, and expanded notes are shown
+// below their lines.
+func (m *Model) layoutDetail() {
+	rows := make([]string, 0, len(m.currentLines))
+	m.displayMap = make([]int, 0, len(m.currentLines))
+	m.contentRow = make([]int, len(m.currentLines))
+
+	next := 0 // first note not yet placed
+	for i, line := range m.currentLines {
+		m.contentRow[i] = len(rows)
+		rows = append(rows, m.noteGutter(i)+renderStyledLine(line, nil, lipgloss.Style{}))
+		m.displayMap = append(m.displayMap, i)
+
+		for ; next < len(m.pageNotes) && m.pageNotes[next].line == i; next++ {
+			if !m.expandedNotes[next] {
+				continue
+			}
+			for _, row := range m.noteRows(m.pageNotes[next]) {
+				rows = append(rows, row)
+				m.displayMap = append(m.displayMap, -1)
+			}
+		}
+	}
+	m.displayRows = rows
+	m.viewport.SetContent(strings.Join(rows, "\n"))
+}
+
+// noteGutter returns the margin for line: a marker if a note is anchored to
+// it, hollow if the note's line could not be found again. Pages without
+// notes have no margin.
+func (m Model) noteGutter(line int) string {
+	if len(m.pageNotes) == 0 {
+		return ""
+	}
+	for _, note := range m.pageNotes {
+		if note.line == line {
+			if note.exact {
+				return noteMarkStyle.Render("◆ ")
+			}
+			return noteMarkStyle.Render("◇ ")
+		}
+	}
+	return "  "
+}
+
+// noteRows renders an expanded note for display under its line
+func (m Model) noteRows(note placedNote) []string {
+	header := "✎ " + note.String()
+	if !note.exact {
+		header += " (its line has changed; shown at the closest place)"
+	}
+	rows := []string{noteStyle.Render("  ┃ " + header)}
+
+	width := max(m.viewport.Width-6, 20)
+	for _, line := range strings.Split(note.Body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			rows = append(rows, noteStyle.Render("  ┃"))
+			continue
+		}
+		for _, wrapped := range strings.Split(wrapText(line, width), "\n") {
+			rows = append(rows, noteStyle.Render("  ┃"+wrapped))
+		}
+	}
+	return rows
+}
+
+// topLine returns the page line at the top of the detail view
+func (m Model) topLine() int {
+	row := m.viewport.YOffset
+	if row >= len(m.displayMap) {
+		return row
+	}
+	// Expanded notes belong to the line above them
+	for row > 0 && m.displayMap[row] < 0 {
+		row--
+	}
+	return m.displayMap[row]
+}
+
+// scrollToLine scrolls the detail view so page line is at the top
+func (m *Model) scrollToLine(line int) {
+	if line >= 0 && line < len(m.contentRow) {
+		line = m.contentRow[line]
+	}
+	m.viewport.SetYOffset(line)
+}
+
+// startNote opens the note input for the first non-blank line on screen
+func (m *Model) startNote() tea.Cmd {
+	lines := strings.Split(m.currentContent, "\n")
+	line := m.firstTextLine()
+	if line >= len(lines) {
+		return nil
+	}
+
+	heading, offset := headingAnchor(lines, line)
+	m.pendingNote = Note{Heading: heading, Line: offset}
+	if !isSectionHeading(lines[line]) {
+		m.pendingNote.Quote = collapseSpaces(lines[line])
+	}
+	m.mode = noteInputView
+	m.noteInput.SetValue("")
+	m.noteInput.Focus()
+	return textinput.Blink
+}
+
+// firstTextLine returns the first line on screen that isn't blank, or the
+// top line if they all are
+func (m Model) firstTextLine() int {
+	lines := strings.Split(m.currentContent, "\n")
+	line := m.topLine()
+	for i := line; i < len(lines) && i < line+m.viewport.Height; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return line
+}
+
+// toggleNoteOnScreen expands or collapses the first note anchored to a line
+// on screen
+func (m *Model) toggleNoteOnScreen() {
+	top := m.topLine()
+	for i, note := range m.pageNotes {
+		if note.line >= top && note.line < top+m.viewport.Height {
+			if m.expandedNotes[i] {
+				delete(m.expandedNotes, i)
+			} else {
+				if m.expandedNotes == nil {
+					m.expandedNotes = make(map[int]bool)
+				}
+				m.expandedNotes[i] = true
+			}
+			m.relayoutDetail()
+			return
+		}
+	}
+	m.status = "No notes on screen"
+}
+
+// toggleAllNotes expands every note, or collapses them all if any is expanded
+func (m *Model) toggleAllNotes() {
+	if len(m.pageNotes) == 0 {
+		m.status = "No notes on this page"
+		return
+	}
+	if len(m.expandedNotes) == 0 {
+		m.expandedNotes = make(map[int]bool)
+		for i := range m.pageNotes {
+			m.expandedNotes[i] = true
+		}
+	} else {
+		m.expandedNotes = nil
+	}
+	m.relayoutDetail()
+}
+
+// jumpToNote scrolls to the next note below the top line, or the previous
+// one above it when step is negative
+func (m *Model) jumpToNote(step int) {
+	top := m.topLine()
+	if step > 0 {
+		for _, note := range m.pageNotes {
+			if note.line > top {
+				m.scrollToLine(note.line)
+				return
+			}
+		}
+	} else {
+		for i := len(m.pageNotes) - 1; i >= 0; i-- {
+			if m.pageNotes[i].line < top {
+				m.scrollToLine(m.pageNotes[i].line)
+				return
+			}
+		}
+	}
+	m.status = "No more notes"
+}
+
+// relayoutDetail lays the page out again, keeping the top line in place
+func (m *Model) relayoutDetail() {
+	top := m.topLine()
+	m.layoutDetail()
+	m.scrollToLine(top)
+}
+
+// editNotes opens the open page's note file in $VISUAL or $EDITOR
+func (m *Model) editNotes() tea.Cmd {
+	page := m.currentPage
+	path, err := m.notes.EnsureFile(page.Name, page.Section)
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return notesEditedMsg{page: page, err: err}
+	})
+}
+
+// updateNoteInputView handles keys while a note is being written
+func (m Model) updateNoteInputView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = detailView
+		m.noteInput.Blur()
+
+	case "enter":
+		m.mode = detailView
+		m.noteInput.Blur()
+		body := strings.TrimSpace(m.noteInput.Value())
+		if body == "" {
+			break
+		}
+		note := m.pendingNote
+		note.Body = body
+		if err := m.notes.Add(m.currentPage.Name, m.currentPage.Section, note); err != nil {
+			m.status = err.Error()
+			break
+		}
+		// Indexes into the page's notes have changed
+		m.expandedNotes = nil
+		m.placeNotes()
+		m.relayoutDetail()
+		m.status = "Note added at " + note.String()
+
+	default:
+		var cmd tea.Cmd
+		m.noteInput, cmd = m.noteInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// renderNoteInputView renders the prompt for a new note
+func (m Model) renderNoteInputView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf(" Note on %s(%s) ", m.currentPage.Name, m.currentPage.Section)))
+	b.WriteString("\n\n")
+	b.WriteString(statusStyle.Render("  At " + m.pendingNote.String()))
+	b.WriteString("\n")
+	if m.pendingNote.Quote != "" {
+		b.WriteString(statusStyle.Render("  > " + m.pendingNote.Quote))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n  ")
+	b.WriteString(m.noteInput.View())
+	b.WriteString("\n")
+
+	b.WriteString(helpStyle.Render("enter save • esc cancel • for longer notes, press e while reading to edit the notes file"))
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	renderCacheSize  = 64 // rendered pages kept in memory
+	prefetchWorkers  = 2  // man processes allowed to run for prefetching at once
+	prefetchDistance = 2  // pages on each side of the cursor to prefetch
+)
+
+// renderKey identifies a rendered page; the same page rendered at a
+// different width is a different entry
+type renderKey struct {
+	name    string
+	section string
+	width   int
+}
+
+// pageKey returns the cache key for page rendered at width
+func pageKey(page ManPage, width int) renderKey {
+	return renderKey{name: page.Name, section: page.Section, width: width}
+}
+
+type renderEntry struct {
+	key     renderKey
+	content string
+}
+
+// RenderCache is a fixed-size LRU of rendered man pages
+type RenderCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[renderKey]*list.Element
+}
+
+// NewRenderCache creates a cache holding up to capacity pages
+func NewRenderCache(capacity int) *RenderCache {
+	return &RenderCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[renderKey]*list.Element),
+	}
+}
+
+// Get returns the cached content for key, marking it recently used
+func (c *RenderCache) Get(key renderKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*renderEntry).content, true
+}
+
+// Add stores content for key, evicting the least recently used page if full
+func (c *RenderCache) Add(key renderKey, content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*renderEntry).content = content
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&renderEntry{key: key, content: content})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderEntry).key)
+	}
+}
+
+// renderCall is a render in progress that other callers can wait on
+type renderCall struct {
+	done    chan struct{}
+	content string
+	err     error
+}
+
+var (
+	renderedPages = NewRenderCache(renderCacheSize)
+
+	renderMu       sync.Mutex
+	renderInFlight = make(map[renderKey]*renderCall)
+)
+
+// renderPage returns the rendered page, from the cache when possible. If the
+// page is already being rendered, e.g. by a prefetch, it waits for that
+// render instead of starting another man process.
+func renderPage(name, section string, width int) (string, error) {
+	key := renderKey{name: name, section: section, width: width}
+	if content, ok := renderedPages.Get(key); ok {
+		return content, nil
+	}
+
+	renderMu.Lock()
+	if call, ok := renderInFlight[key]; ok {
+		renderMu.Unlock()
+		<-call.done
+		return call.content, call.err
+	}
+	call := &renderCall{done: make(chan struct{})}
+	renderInFlight[key] = call
+	renderMu.Unlock()
+
+	call.content, call.err = cachedManContent(name, section, width)
+	if call.err == nil {
+		renderedPages.Add(key, call.content)
+	}
+
+	renderMu.Lock()
+	delete(renderInFlight, key)
+	renderMu.Unlock()
+	close(call.done)
+
+	return call.content, call.err
+}
+
+// Prefetcher renders pages in the background with a fixed number of
+// workers. Each request replaces the pages still waiting, so scrolling past
+// a page drops its prefetch instead of queueing it.
+type Prefetcher struct {
+	mu      sync.Mutex
+	pending []renderKey
+	wake    chan struct{}
+	started sync.Once
+}
+
+var prefetcher = &Prefetcher{wake: make(chan struct{}, 1)}
+
+// Request replaces the pending prefetches with keys
+func (p *Prefetcher) Request(keys []renderKey) {
+	p.started.Do(func() {
+		for i := 0; i < prefetchWorkers; i++ {
+			go p.work()
+		}
+	})
+
+	p.mu.Lock()
+	p.pending = p.pending[:0]
+	for _, key := range keys {
+		if _, ok := renderedPages.Get(key); !ok {
+			p.pending = append(p.pending, key)
+		}
+	}
+	p.mu.Unlock()
+
+	p.signal()
+}
+
+// next pops the next pending key, waking another worker if more remain
+func (p *Prefetcher) next() (renderKey, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.pending) == 0 {
+		return renderKey{}, false
+	}
+	key := p.pending[0]
+	p.pending = p.pending[1:]
+	if len(p.pending) > 0 {
+		p.signal()
+	}
+	return key, true
+}
+
+// signal wakes one idle worker, if any
+func (p *Prefetcher) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Prefetcher) work() {
+	for range p.wake {
+		for {
+			key, ok := p.next()
+			if !ok {
+				break
+			}
+			// Errors are left for the foreground render to report
+			renderPage(key.name, key.section, key.width)
+		}
+	}
+}
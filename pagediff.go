@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// diffStatus says how a section, option or line differs between versions
+type diffStatus byte
+
+const (
+	diffAdded   diffStatus = '+'
+	diffRemoved diffStatus = '-'
+	diffChanged diffStatus = '~'
+)
+
+// diffLine is a line added to or removed from the text of a section or
+// option; unchanged lines aren't kept
+type diffLine struct {
+	status diffStatus
+	text   string
+}
+
+// OptionDiff is an option added, removed or documented differently
+type OptionDiff struct {
+	Entry  string // e.g. "-z, --gzip"
+	Status diffStatus
+	Lines  []diffLine // changes to its description
+}
+
+// SectionDiff is a section added, removed or changed
+type SectionDiff struct {
+	Heading string
+	Status  diffStatus
+	Options []OptionDiff
+	Lines   []diffLine // changes to the text outside its options
+}
+
+// PageDiff is how a page changed between a snapshot and the installed
+// version, by section and by option
+type PageDiff struct {
+	Page     ManPage
+	From     time.Time // when the old version was snapshotted
+	Sections []SectionDiff
+}
+
+// diffEntry is an option documented in a section: its flags, and the lines
+// up to the next option
+type diffEntry struct {
+	head  string
+	flags []string
+	body  []string
+}
+
+// DiffPages compares an old version of a page source with the current one
+func DiffPages(page ManPage, oldSource, newSource string) PageDiff {
+	oldPage, newPage := ParseRoff(oldSource), ParseRoff(newSource)
+	diff := PageDiff{Page: page}
+
+	for _, heading := range mergeHeadings(sectionOrder(oldPage), sectionOrder(newPage)) {
+		oldText, inOld := oldPage.Sections[heading]
+		newText, inNew := newPage.Sections[heading]
+		switch {
+		case !inOld:
+			diff.Sections = append(diff.Sections, SectionDiff{Heading: heading, Status: diffAdded})
+		case !inNew:
+			diff.Sections = append(diff.Sections, SectionDiff{Heading: heading, Status: diffRemoved})
+		case oldText != newText:
+			diff.Sections = append(diff.Sections, diffSection(heading, oldText, newText))
+		}
+	}
+	return diff
+}
+
+// sectionOrder returns the headings of a parsed page in page order
+func sectionOrder(page RoffPage) []string {
+	var headings []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(page.Text, "\n") {
+		if _, ok := page.Sections[line]; ok && !seen[line] {
+			seen[line] = true
+			headings = append(headings, line)
+		}
+	}
+	return headings
+}
+
+// mergeHeadings lists the headings of both versions, in the new version's
+// order with removed sections after the heading they followed
+func mergeHeadings(old, new []string) []string {
+	inNew := make(map[string]bool, len(new))
+	for _, heading := range new {
+		inNew[heading] = true
+	}
+	merged := append([]string(nil), new...)
+	for i, heading := range old {
+		if inNew[heading] {
+			continue
+		}
+		at := 0
+		if i > 0 {
+			for j, h := range merged {
+				if h == old[i-1] {
+					at = j + 1
+				}
+			}
+		}
+		merged = append(merged[:at], append([]string{heading}, merged[at:]...)...)
+	}
+	return merged
+}
+
+// diffSection compares a section's options one by one, and the rest of its
+// text line by line
+func diffSection(heading, oldText, newText string) SectionDiff {
+	section := SectionDiff{Heading: heading, Status: diffChanged}
+	oldIntro, oldEntries := splitEntries(oldText)
+	newIntro, newEntries := splitEntries(newText)
+	section.Lines = diffLines(oldIntro, newIntro)
+
+	matched := make([]bool, len(oldEntries))
+	for _, entry := range newEntries {
+		i := matchEntry(entry, oldEntries, matched)
+		switch {
+		case i < 0:
+			section.Options = append(section.Options, OptionDiff{Entry: entry.head, Status: diffAdded})
+		case oldEntries[i].head != entry.head || strings.Join(oldEntries[i].body, "\n") != strings.Join(entry.body, "\n"):
+			matched[i] = true
+			lines := diffLines(oldEntries[i].body, entry.body)
+			if oldEntries[i].head != entry.head {
+				lines = append([]diffLine{{diffRemoved, oldEntries[i].head}, {diffAdded, entry.head}}, lines...)
+			}
+			section.Options = append(section.Options, OptionDiff{Entry: entry.head, Status: diffChanged, Lines: lines})
+		default:
+			matched[i] = true
+		}
+	}
+	for i, entry := range oldEntries {
+		if !matched[i] {
+			section.Options = append(section.Options, OptionDiff{Entry: entry.head, Status: diffRemoved})
+		}
+	}
+	return section
+}
+
+// splitEntries splits a section's text into the lines before its first
+// option and the options
+func splitEntries(text string) ([]string, []diffEntry) {
+	var intro []string
+	var entries []diffEntry
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "-") {
+			if flags := optionFlags(trimmed); len(flags) > 0 {
+				entries = append(entries, diffEntry{head: trimmed, flags: flags})
+				continue
+			}
+		}
+		if n := len(entries); n > 0 {
+			entries[n-1].body = append(entries[n-1].body, trimmed)
+		} else {
+			intro = append(intro, trimmed)
+		}
+	}
+	return intro, entries
+}
+
+// matchEntry returns the unmatched old entry documenting the same option as
+// entry, or -1
+func matchEntry(entry diffEntry, old []diffEntry, matched []bool) int {
+	for i, candidate := range old {
+		if matched[i] {
+			continue
+		}
+		for _, flag := range entry.flags {
+			for _, oldFlag := range candidate.flags {
+				if flag == oldFlag {
+					return i
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// diffLines returns the lines removed from old and added in new, in order,
+// by longest common subsequence
+func diffLines(old, new []string) []diffLine {
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i, j = i+1, j+1
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{diffRemoved, old[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{diffAdded, new[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// Empty reports whether the versions had no differences
+func (d PageDiff) Empty() bool {
+	return len(d.Sections) == 0
+}
+
+// Format lays the diff out as text: each line starts with its indent and
+// then "+", "-" or "~" for what was added, removed or changed
+func (d PageDiff) Format() []string {
+	lines := []string{fmt.Sprintf("%s(%s): snapshot of %s → installed version", d.Page.Name, d.Page.Section, d.From.Format("2006-01-02 15:04"))}
+	if d.Empty() {
+		return append(lines, "", "No changes")
+	}
+
+	for _, section := range d.Sections {
+		lines = append(lines, "")
+		switch section.Status {
+		case diffAdded:
+			lines = append(lines, fmt.Sprintf("+ %s (new section)", section.Heading))
+			continue
+		case diffRemoved:
+			lines = append(lines, fmt.Sprintf("- %s (section removed)", section.Heading))
+			continue
+		}
+		lines = append(lines, "~ "+section.Heading)
+		for _, option := range section.Options {
+			switch option.Status {
+			case diffAdded:
+				lines = append(lines, "    + "+option.Entry+"  (new option)")
+			case diffRemoved:
+				lines = append(lines, "    - "+option.Entry+"  (option removed)")
+			default:
+				lines = append(lines, "    ~ "+option.Entry)
+				for _, line := range option.Lines {
+					lines = append(lines, fmt.Sprintf("        %c %s", line.status, line.text))
+				}
+			}
+		}
+		if len(section.Lines) > 0 {
+			if len(section.Options) > 0 {
+				lines = append(lines, "    ~ text outside the options")
+			}
+			for _, line := range section.Lines {
+				lines = append(lines, fmt.Sprintf("        %c %s", line.status, line.text))
+			}
+		}
+	}
+	return lines
+}
+
+// snapshotBefore returns the snapshot to compare the installed page with:
+// the latest taken at or before since, or with no date the latest that
+// differs from the installed source. It falls back on the closest snapshot
+// there is.
+func snapshotBefore(versions []Snapshot, currentHash string, since time.Time) (Snapshot, bool) {
+	if len(versions) == 0 {
+		return Snapshot{}, false
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if since.IsZero() && versions[i].Hash != currentHash {
+			return versions[i], true
+		}
+		if !since.IsZero() && !versions[i].Taken.After(since) {
+			return versions[i], true
+		}
+	}
+	if since.IsZero() {
+		// Unchanged since every snapshot
+		return versions[len(versions)-1], true
+	}
+	// Every snapshot is newer than since; the oldest is the closest
+	return versions[0], true
+}
+
+// DiffInstalledPage compares the installed version of page with a snapshot
+// of it, chosen by snapshotBefore
+func DiffInstalledPage(snapshots *SnapshotStore, page ManPage, since time.Time) (PageDiff, error) {
+	path := findManPagePath(page.Name, page.Section)
+	if path == "" {
+		return PageDiff{}, fmt.Errorf("no source file found for %s(%s)", page.Name, page.Section)
+	}
+	current, err := GetRawManContent(path)
+	if err != nil {
+		return PageDiff{}, fmt.Errorf("failed to read %s(%s): %w", page.Name, page.Section, err)
+	}
+	sum := sha256.Sum256([]byte(current))
+
+	snapshot, ok := snapshotBefore(snapshots.Versions(page.Name, page.Section), hex.EncodeToString(sum[:]), since)
+	if !ok {
+		return PageDiff{}, fmt.Errorf("no snapshots of %s(%s) yet", page.Name, page.Section)
+	}
+	old, err := snapshots.Source(snapshot)
+	if err != nil {
+		return PageDiff{}, err
+	}
+	diff := DiffPages(page, old, current)
+	diff.From = snapshot.Taken
+	return diff, nil
+}
+
+// parseSinceDate parses a --since date, either 2006-01-02 (local midnight)
+// or RFC 3339
+func parseSinceDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, nil
+}
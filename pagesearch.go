@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// findHistoryLimit is how many in-page searches up and down go back through
+const findHistoryLimit = 50
+
+var (
+	findMatchStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("226")).
+			Foreground(lipgloss.Color("0")).
+			Bold(true)
+
+	// The match n and N are on
+	findCurrentStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("208")).
+				Foreground(lipgloss.Color("0")).
+				Bold(true)
+
+	findFlagOnStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("170")).
+			Bold(true)
+)
+
+// findFlags are the in-page search modes. Keys toggle them for every search;
+// prefixes on a query override them for that search.
+type findFlags struct {
+	regex     bool // the query is a regular expression
+	smartCase bool // case-sensitive when the query has capitals
+	wholeWord bool // matches must start and end at word boundaries
+}
+
+// findMatch is a match of the detail view's search: bytes [start, end) of a
+// page line
+type findMatch struct {
+	line, start, end int
+}
+
+// findInput is the state of the detail view's search input
+type findInput struct {
+	flags   findFlags
+	history []string // searches made, oldest first
+	pos     int      // history entry shown in the input; len(history) when none is
+	err     error    // why the typed query can't be searched
+	count   int      // matches for the typed query
+
+	returnMode viewMode // view the input was opened over
+}
+
+// compileFind turns a query typed in the detail view into a pattern. As in
+// vim, prefixes override flags for one search: \v makes it a regex, \< a
+// whole word (with an optional closing \>), \c ignores case and \C matches
+// it.
+func compileFind(query string, flags findFlags) (*regexp.Regexp, error) {
+	forceCase := ""
+prefixes:
+	for len(query) >= 2 {
+		switch query[:2] {
+		case `\v`:
+			flags.regex = true
+		case `\<`:
+			flags.wholeWord = true
+			query = strings.TrimSuffix(query, `\>`)
+		case `\c`, `\C`:
+			forceCase = query[:2]
+		default:
+			break prefixes
+		}
+		query = query[2:]
+	}
+	if query == "" {
+		return nil, errors.New("nothing to search for")
+	}
+
+	caseSensitive := flags.smartCase && strings.IndexFunc(query, unicode.IsUpper) >= 0
+	switch forceCase {
+	case `\c`:
+		caseSensitive = false
+	case `\C`:
+		caseSensitive = true
+	}
+
+	pattern := query
+	if !flags.regex {
+		pattern = regexp.QuoteMeta(query)
+	}
+	if flags.wholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	var syntaxErr *syntax.Error
+	if errors.As(err, &syntaxErr) {
+		// The expression in the error has the flags added above; leave it out
+		return nil, fmt.Errorf("invalid regex: %s", syntaxErr.Code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return re, nil
+}
+
+// termsPattern matches any of terms regardless of case, preferring the
+// longest where they overlap
+func termsPattern(terms []string) *regexp.Regexp {
+	var quoted []string
+	for _, term := range terms {
+		if term != "" {
+			quoted = append(quoted, regexp.QuoteMeta(term))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// findAll returns every non-empty match of pattern in content, in order
+func findAll(content string, pattern *regexp.Regexp) []findMatch {
+	if pattern == nil || content == "" {
+		return nil
+	}
+	var matches []findMatch
+	for i, line := range strings.Split(content, "\n") {
+		for _, loc := range pattern.FindAllStringIndex(line, -1) {
+			if loc[1] > loc[0] {
+				matches = append(matches, findMatch{line: i, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	return matches
+}
+
+// lineMatches returns the matches on line and the index of the first of them
+// in matches
+func lineMatches(matches []findMatch, line int) ([]findMatch, int) {
+	first := sort.Search(len(matches), func(i int) bool { return matches[i].line >= line })
+	last := first
+	for last < len(matches) && matches[last].line == line {
+		last++
+	}
+	return matches[first:last], first
+}
+
+// addFindHistory remembers query as the latest search, moving it to the end
+// if it was searched before
+func (f *findInput) addFindHistory(query string) {
+	for i, past := range f.history {
+		if past == query {
+			f.history = append(f.history[:i], f.history[i+1:]...)
+			break
+		}
+	}
+	f.history = append(f.history, query)
+	if len(f.history) > findHistoryLimit {
+		f.history = f.history[len(f.history)-findHistoryLimit:]
+	}
+	f.pos = len(f.history)
+}
+
+// renderFindFlags shows which search modes are on, with their keys
+func (f findInput) renderFindFlags() string {
+	flags := []struct {
+		key, name string
+		on        bool
+	}{
+		{"alt+r", "regex", f.flags.regex},
+		{"alt+c", "smart case", f.flags.smartCase},
+		{"alt+w", "whole word", f.flags.wholeWord},
+	}
+	parts := make([]string, len(flags))
+	for i, flag := range flags {
+		if flag.on {
+			parts[i] = findFlagOnStyle.Render("[x] "+flag.name) + statusStyle.Render(" "+flag.key)
+		} else {
+			parts[i] = statusStyle.Render("[ ] " + flag.name + " " + flag.key)
+		}
+	}
+	return "  " + strings.Join(parts, statusStyle.Render("   "))
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startFind opens the search input over the detail view or line filter
+func (m *Model) startFind() tea.Cmd {
+	m.find.returnMode = m.mode
+	m.mode = detailSearchView
+	m.detailSearchInput.SetValue("")
+	m.detailSearchInput.Focus()
+	m.find.pos = len(m.find.history)
+	m.find.err = nil
+	m.find.count = 0
+	return textinput.Blink
+}
+
+// previewFind checks the query being typed, counting its matches or keeping
+// why it can't be searched
+func (m *Model) previewFind() {
+	m.find.err = nil
+	m.find.count = 0
+	query := m.detailSearchInput.Value()
+	if query == "" {
+		return
+	}
+	pattern, err := compileFind(query, m.find.flags)
+	if err != nil {
+		m.find.err = err
+		return
+	}
+	m.find.count = len(m.findMatches(pattern))
+}
+
+// jumpToMatch moves to the next search match, or the previous one when step
+// is negative
+func (m *Model) jumpToMatch(step int) {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+	m.currentMatch = (m.currentMatch + step + len(m.searchMatches)) % len(m.searchMatches)
+	m.scrollToLine(m.searchMatches[m.currentMatch].line)
+}
+
+// matchIndicator describes the search and where the current match is in it
+func (m Model) matchIndicator() string {
+	if len(m.searchMatches) == 0 {
+		return fmt.Sprintf("[Search: %s - no matches]", m.searchQuery)
+	}
+	return fmt.Sprintf("[Search: %s - match %d/%d]", m.searchQuery, m.currentMatch+1, len(m.searchMatches))
+}
+
+// updateDetailSearchView handles keys while a search of the open page is
+// typed
+func (m Model) updateDetailSearchView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = m.find.returnMode
+		m.detailSearchInput.Blur()
+		return m, nil
+
+	case "enter":
+		query := m.detailSearchInput.Value()
+		if query == "" {
+			m.mode = m.find.returnMode
+			m.detailSearchInput.Blur()
+			return m, nil
+		}
+		pattern, err := compileFind(query, m.find.flags)
+		if err != nil {
+			// Stay in the input so the query can be fixed
+			m.find.err = err
+			return m, nil
+		}
+		m.find.addFindHistory(query)
+		m.searchQuery = query
+		m.searchPattern = pattern
+		m.searchMatches = m.findMatches(pattern)
+		m.currentMatch = 0
+		if len(m.searchMatches) > 0 {
+			m.scrollToLine(m.searchMatches[0].line)
+		}
+		m.mode = m.find.returnMode
+		m.detailSearchInput.Blur()
+		if m.mode == filterView {
+			// Move to the first line shown with a match
+			m.jumpFilterMatch(0)
+		}
+		return m, nil
+
+	case "up", "down":
+		// Earlier searches
+		if msg.String() == "up" && m.find.pos > 0 {
+			m.find.pos--
+		} else if msg.String() == "down" && m.find.pos < len(m.find.history) {
+			m.find.pos++
+		} else {
+			return m, nil
+		}
+		query := ""
+		if m.find.pos < len(m.find.history) {
+			query = m.find.history[m.find.pos]
+		}
+		m.detailSearchInput.SetValue(query)
+		m.detailSearchInput.CursorEnd()
+
+	case "alt+r":
+		m.find.flags.regex = !m.find.flags.regex
+
+	case "alt+c":
+		m.find.flags.smartCase = !m.find.flags.smartCase
+
+	case "alt+w":
+		m.find.flags.wholeWord = !m.find.flags.wholeWord
+
+	default:
+		var cmd tea.Cmd
+		m.detailSearchInput, cmd = m.detailSearchInput.Update(msg)
+		m.previewFind()
+		return m, cmd
+	}
+
+	m.previewFind()
+	return m, nil
+}
+
+func (m Model) renderDetailSearchView() string {
+	var b strings.Builder
+
+	title := titleStyle.Render(" Search in Document ")
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	b.WriteString("  ")
+	b.WriteString(m.detailSearchInput.View())
+	b.WriteString("\n")
+	b.WriteString(m.find.renderFindFlags())
+	b.WriteString("\n\n")
+
+	switch {
+	case m.find.err != nil:
+		b.WriteString(errorStyle.Render("  " + m.find.err.Error()))
+		b.WriteString("\n")
+	case m.detailSearchInput.Value() != "":
+		b.WriteString(statusStyle.Render(fmt.Sprintf("  %d matches", m.find.count)))
+		b.WriteString("\n")
+	}
+
+	help := helpStyle.Render(`enter search • esc cancel • ↑/↓ earlier searches • prefixes: \v regex, \< whole word, \c ignore case, \C match case`)
+	b.WriteString(help)
+
+	return b.String()
+}
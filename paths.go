@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// dataLockTimeout is how long saving a data file waits for another lazyman
+// saving it; a lock held longer was left behind by one that crashed
+const dataLockTimeout = 10 * time.Second
+
+// configDir returns lazyman's configuration directory (e.g. ~/.config/lazyman)
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lazyman"), nil
+}
+
+// cacheDir returns lazyman's cache directory (e.g. ~/.cache/lazyman)
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lazyman"), nil
+}
+
+// dataDir returns lazyman's data directory for files the user curates, such
+// as bookmarks: $XDG_DATA_HOME/lazyman or ~/.local/share/lazyman, and the
+// config directory on macOS and Windows, which have no separate data location.
+// A file missing from it is empty, and one that can't be read is reported
+// and left alone rather than overwritten.
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "lazyman"), nil
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return configDir()
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "lazyman"), nil
+}
+
+// writeFileAtomic replaces the file at path with data, creating its
+// directory. The data is written to a temporary file renamed over path, so
+// readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// lockFile takes a lock on path by creating it, and its directory, waiting
+// up to timeout for whoever holds it. A lock older than timeout is taken
+// over. The returned function releases it.
+func lockFile(path string, timeout time.Duration) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > timeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another lazyman", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
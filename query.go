@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// queryFields maps the field prefixes users type to index fields
+var queryFields = map[string]string{
+	"name":        "Name",
+	"section":     "Section",
+	"desc":        "Description",
+	"description": "Description",
+	"content":     "Content",
+}
+
+// scopeFields maps section-heading prefixes to the index field holding that section
+var scopeFields = map[string]string{
+	"synopsis": "Synopsis",
+	"options":  "Options",
+	"examples": "Examples",
+	"errors":   "Errors",
+	"env":      "Environment",
+	"files":    "Files",
+	"seealso":  "SeeAlso",
+}
+
+// scopeHeadings maps man page headings to the scope field they are indexed under
+var scopeHeadings = map[string]string{
+	"SYNOPSIS":    "Synopsis",
+	"OPTIONS":     "Options",
+	"EXAMPLES":    "Examples",
+	"EXAMPLE":     "Examples",
+	"ERRORS":      "Errors",
+	"ENVIRONMENT": "Environment",
+	"FILES":       "Files",
+	"SEE ALSO":    "SeeAlso",
+}
+
+// keywordFields are indexed verbatim, so query terms are not lower-cased
+var keywordFields = map[string]bool{
+	"Section": true,
+}
+
+// QuerySyntaxHelp documents the deep search query language
+const QuerySyntaxHelp = `Deep search query syntax:
+
+  socket                  pages mentioning "socket" anywhere
+  "non blocking"          exact phrase
+  +socket +bind           both terms are required
+  -deprecated             exclude pages mentioning "deprecated"
+  pthread_*  fil?         wildcards: * any run of characters, ? one character
+  recieve~  recieve~2     fuzzy match within 1 (default) or 2 edits
+
+Fields:
+  name:     page name                 section:  manual section (e.g. section:2)
+  desc:     NAME line description     content:  full page text
+
+Section scopes (match only inside that part of the page):
+  synopsis:  options:  examples:  errors:  env:  files:  seealso:
+
+Fields and scopes filter: every field given must match, and a field given
+twice matches either value, so  section:2 section:3 options:flags  finds
+pages in section 2 or 3 with "flags" in their options. Bare terms only rank.
+
+Combine freely, e.g.  name:tar options:"--zstd" -section:1p`
+
+// QueryError describes a deep search query that could not be parsed
+type QueryError struct {
+	Query string
+	Pos   int // byte offset in Query where the problem was found
+	Msg   string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at column %d: %s", e.Pos+1, e.Msg)
+}
+
+// Pretty renders the error with the query and a caret under the offending column
+func (e *QueryError) Pretty() string {
+	return fmt.Sprintf("%s\n  %s\n  %s^", e.Error(), e.Query, strings.Repeat(" ", e.Pos))
+}
+
+// queryClause is a single whitespace-separated part of a query
+type queryClause struct {
+	pos       int
+	required  bool
+	excluded  bool
+	field     string // index field, empty for all fields
+	value     string
+	phrase    bool
+	fuzziness int // 0 when not fuzzy
+}
+
+// ParseQuery parses the deep search query language into a bleve query
+func ParseQuery(input string) (query.Query, error) {
+	clauses, err := parseClauses(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(clauses) == 0 {
+		return nil, &QueryError{Query: input, Pos: 0, Msg: "query is empty"}
+	}
+
+	bq := bleve.NewBooleanQuery()
+	hasPositive := false
+	// Bare field clauses are filters: each field must match, any of its values
+	fieldQueries := make(map[string]*query.DisjunctionQuery)
+	for _, c := range clauses {
+		q := c.toQuery()
+		switch {
+		case c.excluded:
+			bq.AddMustNot(q)
+		case c.required:
+			bq.AddMust(q)
+			hasPositive = true
+		case c.field != "":
+			if dq, ok := fieldQueries[c.field]; ok {
+				dq.AddQuery(q)
+				continue
+			}
+			dq := bleve.NewDisjunctionQuery(q)
+			fieldQueries[c.field] = dq
+			bq.AddMust(dq)
+			hasPositive = true
+		default:
+			bq.AddShould(q)
+			hasPositive = true
+		}
+	}
+
+	// A query of only exclusions means "everything except"
+	if !hasPositive {
+		bq.AddMust(bleve.NewMatchAllQuery())
+	}
+
+	return bq, nil
+}
+
+// IsPlainQuery reports whether input is free text, with no fields, phrases,
+// operators, wildcards or fuzzy terms
+func IsPlainQuery(input string) bool {
+	clauses, err := parseClauses(input)
+	if err != nil || len(clauses) == 0 {
+		return false
+	}
+	for _, c := range clauses {
+		if c.required || c.excluded || c.field != "" || c.phrase || c.fuzziness > 0 ||
+			strings.ContainsAny(c.value, "*?") {
+			return false
+		}
+	}
+	return true
+}
+
+// parseClauses splits the input into clauses, reporting syntax errors with their position
+func parseClauses(input string) ([]queryClause, error) {
+	var clauses []queryClause
+	fail := func(pos int, format string, args ...interface{}) ([]queryClause, error) {
+		return nil, &QueryError{Query: input, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	i := 0
+	for i < len(input) {
+		if input[i] == ' ' || input[i] == '\t' {
+			i++
+			continue
+		}
+
+		c := queryClause{pos: i}
+		switch input[i] {
+		case '-':
+			c.excluded = true
+			i++
+		case '+':
+			c.required = true
+			i++
+		}
+		if i >= len(input) || input[i] == ' ' {
+			return fail(c.pos, "%q must be followed by a term", input[c.pos])
+		}
+
+		// Optional field prefix
+		if end := fieldPrefixEnd(input, i); end != -1 {
+			name := strings.ToLower(input[i:end])
+			field, ok := queryFields[name]
+			if !ok {
+				field, ok = scopeFields[name]
+			}
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q", name)
+				if s := closestFieldName(name); s != "" {
+					msg += fmt.Sprintf(" (did you mean %s:?)", s)
+				}
+				return fail(i, "%s; see lazyman -S --help", msg)
+			}
+			c.field = field
+			i = end + 1
+			if i >= len(input) || input[i] == ' ' {
+				return fail(i, "%s: needs a value", name)
+			}
+		}
+
+		// Value: quoted phrase or bare term
+		if input[i] == '"' {
+			end := strings.IndexByte(input[i+1:], '"')
+			if end == -1 {
+				return fail(i, "unterminated quote")
+			}
+			c.value = input[i+1 : i+1+end]
+			c.phrase = true
+			if strings.TrimSpace(c.value) == "" {
+				return fail(i, "empty phrase")
+			}
+			i += end + 2
+		} else {
+			var b strings.Builder
+			tilde, tildePos := -1, 0
+			for i < len(input) && input[i] != ' ' && input[i] != '\t' {
+				switch input[i] {
+				case '\\':
+					if i+1 < len(input) {
+						i++
+					}
+				case '"':
+					return fail(i, "quote inside a term; put the whole phrase in quotes")
+				case '~':
+					tilde, tildePos = b.Len(), i
+				}
+				b.WriteByte(input[i])
+				i++
+			}
+			c.value = b.String()
+			if tilde != -1 {
+				fuzz, err := parseFuzziness(c.value[tilde+1:])
+				if err != nil {
+					return fail(tildePos, "%v", err)
+				}
+				if tilde == 0 {
+					return fail(tildePos, "~ must follow a term")
+				}
+				c.value = c.value[:tilde]
+				c.fuzziness = fuzz
+			}
+		}
+
+		if c.fuzziness > 0 && strings.ContainsAny(c.value, "*?") {
+			return fail(c.pos, "a term cannot be both a wildcard and fuzzy")
+		}
+		if i < len(input) && input[i] != ' ' && input[i] != '\t' {
+			return fail(i, "expected a space after the closing quote")
+		}
+
+		clauses = append(clauses, c)
+	}
+
+	return clauses, nil
+}
+
+// fieldPrefixEnd returns the index of the colon ending a "field:" prefix at i, or -1
+func fieldPrefixEnd(input string, i int) int {
+	for j := i; j < len(input); j++ {
+		c := input[j]
+		switch {
+		case c == ':':
+			if j == i {
+				return -1
+			}
+			return j
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			continue
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// parseFuzziness parses the edit distance after "~"
+func parseFuzziness(s string) (int, error) {
+	if s == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > 2 {
+		return 0, fmt.Errorf("fuzziness must be ~, ~1 or ~2")
+	}
+	return n, nil
+}
+
+// closestFieldName suggests a known field for a mistyped prefix
+func closestFieldName(name string) string {
+	names := make([]string, 0, len(queryFields)+len(scopeFields))
+	for n := range queryFields {
+		names = append(names, n)
+	}
+	for n := range scopeFields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", 3
+	for _, n := range names {
+		if d := levenshteinDistance(name, n); d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// toQuery converts a clause to the matching bleve query
+func (c queryClause) toQuery() query.Query {
+	value := c.value
+	if !keywordFields[c.field] {
+		value = strings.ToLower(value)
+	}
+
+	switch {
+	case c.phrase:
+		q := bleve.NewMatchPhraseQuery(c.value)
+		q.SetField(c.field)
+		return q
+	case strings.ContainsAny(value, "*?"):
+		q := bleve.NewWildcardQuery(value)
+		q.SetField(c.field)
+		return q
+	case c.fuzziness > 0:
+		q := bleve.NewFuzzyQuery(value)
+		q.SetFuzziness(c.fuzziness)
+		q.SetField(c.field)
+		return q
+	case keywordFields[c.field]:
+		q := bleve.NewTermQuery(value)
+		q.SetField(c.field)
+		return q
+	default:
+		q := bleve.NewMatchQuery(c.value)
+		q.SetField(c.field)
+		return q
+	}
+}
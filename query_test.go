@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// queryTestIndex returns an in-memory index of a few pages
+func queryTestIndex(t *testing.T) bleve.Index {
+	t.Helper()
+	index, err := bleve.NewMemOnly(buildIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]ManPageDocument{
+		"socket(2)": {Name: "socket", Section: "2", Description: "create an endpoint for communication",
+			Content: "socket creates an endpoint; flags may be given", Options: "SOCK_NONBLOCK flags"},
+		"socket(7)": {Name: "socket", Section: "7", Description: "Linux socket interface",
+			Content: "the socket interface; deprecated options are listed"},
+		"bind(2)": {Name: "bind", Section: "2", Description: "bind a name to a socket",
+			Content: "bind assigns an address to a socket"},
+		"tar(1)": {Name: "tar", Section: "1", Description: "an archiving utility",
+			Content: "tar saves many files together", Options: "--zstd filter the archive through zstd"},
+		"open(3p)": {Name: "open", Section: "3p", Description: "open a file",
+			Content: "open a file with flags"},
+	}
+	for id, doc := range docs {
+		if err := index.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { index.Close() })
+	return index
+}
+
+func TestParseQueryMatches(t *testing.T) {
+	index := queryTestIndex(t)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"socket", []string{"bind(2)", "socket(2)", "socket(7)"}},
+		{"socket -deprecated", []string{"bind(2)", "socket(2)"}},
+		{"-socket", []string{"open(3p)", "tar(1)"}},
+		{"+socket +address", []string{"bind(2)"}},
+		{`"socket interface"`, []string{"socket(7)"}},
+		{"name:socket", []string{"socket(2)", "socket(7)"}},
+		{"section:2", []string{"bind(2)", "socket(2)"}},
+		// section:3 is exact, so it doesn't match 3p
+		{"section:3", nil},
+		{"section:3p", []string{"open(3p)"}},
+		// Fields filter rather than adding optional matches
+		{"section:2 options:flags", []string{"socket(2)"}},
+		// Bare terms only rank
+		{"section:2 section:3p flags", []string{"bind(2)", "open(3p)", "socket(2)"}},
+		{"section:2 socket", []string{"bind(2)", "socket(2)"}},
+		{`name:tar options:"--zstd" -section:1p`, []string{"tar(1)"}},
+		{"name:soc*", []string{"socket(2)", "socket(7)"}},
+		{"archving~", []string{"tar(1)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q): %v", tt.query, err)
+			}
+			request := bleve.NewSearchRequest(q)
+			request.Size = 100
+			results, err := index.Search(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, hit := range results.Hits {
+				got = append(got, hit.ID)
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseQuery(%q) matched %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{"", 0},
+		{"socket -", 7},
+		{"sectoin:2", 0},
+		{"name:", 5},
+		{`"unterminated`, 0},
+		{`so"cket`, 2},
+		{"recieve~3", 7},
+		{"soc*~", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("ParseQuery(%q) = %v, want a QueryError", tt.query, err)
+			}
+			if queryErr.Pos != tt.pos {
+				t.Errorf("ParseQuery(%q) error at %d, want %d: %v", tt.query, queryErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestIsPlainQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"compress a directory", true},
+		{"socket", true},
+		{"section:2 socket", false},
+		{"socket -deprecated", false},
+		{"+socket", false},
+		{`"non blocking"`, false},
+		{"pthread_*", false},
+		{"recieve~", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsPlainQuery(tt.query); got != tt.want {
+			t.Errorf("IsPlainQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// RelatedPage is a page suggested as related to another one
+type RelatedPage struct {
+	ManPage ManPage
+	Score   float64
+	Reasons []string // why the page was suggested, e.g. "see also"
+}
+
+const (
+	// relatedTermCount is how many characteristic terms make up the "more like this" query
+	relatedTermCount = 25
+
+	// relatedCandidateTerms caps how many of a page's terms are weighed for that query
+	relatedCandidateTerms = 300
+
+	// Weights for combining the signals into one score
+	similarityWeight = 1.0
+	seeAlsoWeight    = 1.0
+	prefixWeight     = 0.5
+)
+
+// manReferencePattern matches cross-references such as "poll(2)" or "sd_notify(3)"
+var manReferencePattern = regexp.MustCompile(`([A-Za-z0-9_][A-Za-z0-9_.:+-]*)\s?\((\d[a-zA-Z0-9]*)\)`)
+
+// FindRelatedPages ranks pages related to name(section) by combining term-vector
+// similarity in the search index with SEE ALSO links and shared name prefixes
+func FindRelatedPages(name, section string, limit int) ([]RelatedPage, error) {
+	index, err := openSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	docID := fmt.Sprintf("%s(%s)", name, section)
+	docRequest := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{docID}))
+	docRequest.Fields = []string{"Content"}
+	docResults, err := index.Search(docRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", docID, err)
+	}
+	if len(docResults.Hits) == 0 {
+		return nil, fmt.Errorf("%s is not in the search index", docID)
+	}
+	content := getFieldString(docResults.Hits[0].Fields, "Content")
+
+	candidates := make(map[string]*RelatedPage)
+	add := func(id string, score float64, reason string) {
+		if id == docID {
+			return
+		}
+		c, ok := candidates[id]
+		if !ok {
+			n, s := parseDocID(id)
+			c = &RelatedPage{ManPage: ManPage{Name: n, Section: s}}
+			candidates[id] = c
+		}
+		for _, r := range c.Reasons {
+			if r == reason {
+				return
+			}
+		}
+		c.Score += score
+		c.Reasons = append(c.Reasons, reason)
+	}
+
+	// "More like this": query with the page's most characteristic terms
+	similarQuery, err := moreLikeThisQuery(index, content)
+	if err != nil {
+		return nil, err
+	}
+	if similarQuery != nil {
+		request := bleve.NewSearchRequest(similarQuery)
+		request.Size = limit * 2
+		results, err := index.Search(request)
+		if err != nil {
+			return nil, fmt.Errorf("similarity search failed: %w", err)
+		}
+		if results.MaxScore > 0 {
+			for _, hit := range results.Hits {
+				add(hit.ID, similarityWeight*hit.Score/results.MaxScore, "similar content")
+			}
+		}
+	}
+
+	// SEE ALSO links
+	for _, ref := range seeAlsoReferences(content) {
+		add(ref, seeAlsoWeight, "see also")
+	}
+
+	// Pages sharing the name's prefix, e.g. epoll -> epoll_ctl, epoll_wait
+	prefix := namePrefix(name)
+	prefixQuery := bleve.NewPrefixQuery(strings.ToLower(prefix))
+	prefixQuery.SetField("Name")
+	prefixRequest := bleve.NewSearchRequest(prefixQuery)
+	prefixRequest.Size = limit
+	prefixResults, err := index.Search(prefixRequest)
+	if err != nil {
+		return nil, fmt.Errorf("prefix search failed: %w", err)
+	}
+	for _, hit := range prefixResults.Hits {
+		if n, _ := parseDocID(hit.ID); namePrefix(n) == prefix {
+			add(hit.ID, prefixWeight, "same prefix")
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Fill in descriptions, dropping SEE ALSO links to pages that aren't installed
+	ids := make([]string, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	descRequest := bleve.NewSearchRequest(bleve.NewDocIDQuery(ids))
+	descRequest.Size = len(ids)
+	descRequest.Fields = []string{"Description"}
+	descResults, err := index.Search(descRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up related pages: %w", err)
+	}
+
+	related := make([]RelatedPage, 0, len(descResults.Hits))
+	for _, hit := range descResults.Hits {
+		c := candidates[hit.ID]
+		c.ManPage.Description = getFieldString(hit.Fields, "Description")
+		related = append(related, *c)
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+		return related[i].ManPage.Name < related[j].ManPage.Name
+	})
+	if len(related) > limit {
+		related = related[:limit]
+	}
+
+	return related, nil
+}
+
+// moreLikeThisQuery builds a disjunction of the terms in content with the
+// highest tf-idf weight, or nil if the content has no usable terms
+func moreLikeThisQuery(index bleve.Index, content string) (query.Query, error) {
+	indexMapping := index.Mapping()
+	analyzer := indexMapping.AnalyzerNamed(indexMapping.AnalyzerNameForPath("Content"))
+	if analyzer == nil {
+		return nil, fmt.Errorf("no analyzer for the Content field")
+	}
+
+	termFreq := make(map[string]int)
+	for _, token := range analyzer.Analyze([]byte(content)) {
+		term := string(token.Term)
+		if len(term) < 3 || strings.Trim(term, "0123456789") == "" {
+			continue
+		}
+		termFreq[term]++
+	}
+
+	docCount, err := index.DocCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	// Only look up document frequencies for the most frequent terms; long
+	// pages have thousands of distinct ones
+	terms := make([]string, 0, len(termFreq))
+	for term := range termFreq {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if termFreq[terms[i]] != termFreq[terms[j]] {
+			return termFreq[terms[i]] > termFreq[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > relatedCandidateTerms {
+		terms = terms[:relatedCandidateTerms]
+	}
+
+	type weightedTerm struct {
+		term   string
+		weight float64
+	}
+	weighted := make([]weightedTerm, 0, len(terms))
+	for _, term := range terms {
+		tf := termFreq[term]
+		df, err := documentFrequency(index, "Content", term)
+		if err != nil {
+			return nil, err
+		}
+		// Terms in nearly every page say nothing about this one
+		if df == 0 || float64(df) > float64(docCount)*0.2 {
+			continue
+		}
+		idf := math.Log(float64(docCount) / float64(df))
+		weighted = append(weighted, weightedTerm{term, math.Sqrt(float64(tf)) * idf})
+	}
+	if len(weighted) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(weighted, func(i, j int) bool {
+		return weighted[i].weight > weighted[j].weight
+	})
+	if len(weighted) > relatedTermCount {
+		weighted = weighted[:relatedTermCount]
+	}
+
+	disjuncts := make([]query.Query, 0, len(weighted))
+	for _, w := range weighted {
+		tq := bleve.NewTermQuery(w.term)
+		tq.SetField("Content")
+		tq.SetBoost(w.weight)
+		disjuncts = append(disjuncts, tq)
+	}
+	return bleve.NewDisjunctionQuery(disjuncts...), nil
+}
+
+// documentFrequency returns how many documents contain term in field
+func documentFrequency(index bleve.Index, field, term string) (uint64, error) {
+	dict, err := index.FieldDictRange(field, []byte(term), []byte(term))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read term dictionary: %w", err)
+	}
+	defer dict.Close()
+
+	entry, err := dict.Next()
+	if err != nil || entry == nil {
+		return 0, err
+	}
+	return entry.Count, nil
+}
+
+// seeAlsoReferences returns the "name(section)" IDs listed in a page's SEE ALSO section
+func seeAlsoReferences(content string) []string {
+	var refs []string
+	inSeeAlso := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if isSectionHeading(line) {
+			inSeeAlso = trimmed == "SEE ALSO"
+			continue
+		}
+		if !inSeeAlso {
+			continue
+		}
+		for _, match := range manReferencePattern.FindAllStringSubmatch(line, -1) {
+			refs = append(refs, fmt.Sprintf("%s(%s)", match[1], match[2]))
+		}
+	}
+	return refs
+}
+
+// isSectionHeading reports whether a line of page text is a top-level heading such as "SEE ALSO"
+func isSectionHeading(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || line != strings.TrimLeft(line, " \t") {
+		return false
+	}
+	return trimmed == strings.ToUpper(trimmed) && strings.ContainsAny(trimmed, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+}
+
+// namePrefix returns the part of a page name before its first separator
+func namePrefix(name string) string {
+	if idx := strings.IndexAny(name, "_.-"); idx > 0 {
+		return name[:idx]
+	}
+	return name
+}
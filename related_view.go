@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// relatedPanelWidth is the width of the related pages panel in the detail view
+const relatedPanelWidth = 40
+
+type relatedLoadedMsg struct {
+	key   string
+	pages []RelatedPage
+	err   error
+}
+
+func loadRelated(page ManPage) tea.Cmd {
+	return func() tea.Msg {
+		pages, err := FindRelatedPages(page.Name, page.Section, 15)
+		return relatedLoadedMsg{
+			key:   fmt.Sprintf("%s(%s)", page.Name, page.Section),
+			pages: pages,
+			err:   err,
+		}
+	}
+}
+
+// openRelated shows the related pages panel, loading it for the current page if needed
+func (m *Model) openRelated() tea.Cmd {
+	m.mode = relatedView
+	// The page is re-rendered for the narrower pane beside the panel
+	reflow := m.reflowDetail()
+	key := fmt.Sprintf("%s(%s)", m.currentPage.Name, m.currentPage.Section)
+	if key == m.relatedFor && !m.loadingRelated {
+		return reflow
+	}
+
+	m.relatedFor = key
+	m.related = nil
+	m.relatedErr = nil
+	m.relatedCursor = 0
+	m.loadingRelated = true
+	return tea.Batch(reflow, loadRelated(m.currentPage))
+}
+
+// updateRelatedView handles keys while the related pages panel has focus
+func (m Model) updateRelatedView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc", "R":
+		m.mode = detailView
+		return m, m.reflowDetail()
+
+	case "up", "k":
+		if m.relatedCursor > 0 {
+			m.relatedCursor--
+		}
+
+	case "down", "j":
+		if m.relatedCursor < len(m.related)-1 {
+			m.relatedCursor++
+		}
+
+	case "enter":
+		if m.relatedCursor < len(m.related) {
+			page := m.related[m.relatedCursor].ManPage
+			m.mode = detailView
+			return m, m.openPage(page)
+		}
+	}
+
+	return m, nil
+}
+
+// renderRelatedView renders the detail view with the related pages panel beside it
+func (m Model) renderRelatedView() string {
+	contentWidth := m.width - relatedPanelWidth - 3
+	if contentWidth < 20 {
+		contentWidth = 20
+	}
+	detail := lipgloss.NewStyle().MaxWidth(contentWidth).Render(m.renderDetailView())
+	detail = lipgloss.NewStyle().Width(contentWidth).Render(detail)
+
+	var panel strings.Builder
+	panel.WriteString(titleStyle.Render(" Related "))
+	panel.WriteString("\n\n")
+
+	switch {
+	case m.loadingRelated:
+		panel.WriteString("  Finding related pages...")
+	case m.relatedErr != nil:
+		panel.WriteString(errorStyle.Render(wrapText(m.relatedErr.Error(), relatedPanelWidth-2)))
+	case len(m.related) == 0:
+		panel.WriteString("  No related pages found")
+	default:
+		for i, related := range m.related {
+			line := fmt.Sprintf("%s(%s)", related.ManPage.Name, related.ManPage.Section)
+			if i == m.relatedCursor {
+				panel.WriteString(selectedItemStyle.Render("▸ " + line))
+			} else {
+				panel.WriteString(itemStyle.Render(line))
+			}
+			panel.WriteString("\n")
+			panel.WriteString(statusStyle.Render("    " + strings.Join(related.Reasons, ", ")))
+			panel.WriteString("\n")
+		}
+
+		if m.relatedCursor < len(m.related) {
+			if desc := m.related[m.relatedCursor].ManPage.Description; desc != "" {
+				panel.WriteString("\n")
+				panel.WriteString(statusStyle.Render(wrapText(desc, relatedPanelWidth-2)))
+				panel.WriteString("\n")
+			}
+		}
+	}
+
+	panel.WriteString(helpStyle.Render("↑/k ↓/j select • enter open • esc close"))
+
+	border := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color("240")).
+		PaddingLeft(1).
+		Width(relatedPanelWidth)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, detail, border.Render(panel.String()))
+}
+
+// wrapText wraps text to width columns, indenting each line by two spaces
+func wrapText(text string, width int) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width-2 {
+			lines = append(lines, "  "+line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, "  "+line)
+	}
+	return strings.Join(lines, "\n")
+}
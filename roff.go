@@ -0,0 +1,211 @@
+package main
+
+import (
+	"strings"
+)
+
+// RoffPage is the plain-text form of a man page source file
+type RoffPage struct {
+	Description string            // text after "\-" on the NAME line
+	Sections    map[string]string // section heading (upper case) -> plain text
+	Text        string            // the whole page as plain text
+}
+
+// fontMacros are roff/mdoc requests whose arguments are page text
+var fontMacros = map[string]bool{
+	"B": true, "I": true, "SM": true, "SB": true,
+	"BR": true, "RB": true, "BI": true, "IB": true, "IR": true, "RI": true,
+	"IP": true, "TP": true, "SS": true, "Ss": true,
+	// mdoc
+	"Nm": true, "Nd": true, "Ar": true, "Fl": true, "Op": true, "Cm": true,
+	"Pa": true, "Ev": true, "Va": true, "Fn": true, "Fa": true, "Ft": true,
+	"Xr": true, "Dq": true, "Ql": true, "Sy": true, "Em": true, "Li": true,
+	"It": true, "Dl": true, "Ic": true, "Er": true, "Pq": true, "Sq": true,
+}
+
+// ParseRoff converts raw man/mdoc source into plain text split by section
+func ParseRoff(raw string) RoffPage {
+	page := RoffPage{Sections: make(map[string]string)}
+
+	var text strings.Builder
+	var section strings.Builder
+	heading := ""
+	descNext := false
+
+	flush := func() {
+		if heading != "" {
+			page.Sections[heading] = strings.TrimSpace(section.String())
+		}
+		section.Reset()
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		var out string
+		isHeading := false
+
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			macro, args := splitRequest(line[1:])
+			switch {
+			case macro == "SH" || macro == "Sh":
+				flush()
+				heading = strings.ToUpper(unescapeRoff(joinRoffArgs(args)))
+				out = heading
+				isHeading = true
+			case macro == "Fl":
+				out = "-" + unescapeRoff(joinRoffArgs(args))
+			case fontMacros[macro]:
+				out = unescapeRoff(joinRoffArgs(args))
+			default:
+				// Formatting requests and comments carry no text
+				continue
+			}
+		} else {
+			out = unescapeRoff(line)
+		}
+
+		if heading == "NAME" && !isHeading && page.Description == "" {
+			switch {
+			case descNext:
+				page.Description = strings.TrimSpace(out)
+			case strings.HasSuffix(strings.TrimSpace(out), " -"):
+				// "name \-" with the description on the next line
+				descNext = true
+			case strings.Contains(out, " - "):
+				page.Description = strings.TrimSpace(out[strings.Index(out, " - ")+3:])
+			case strings.HasPrefix(line, ".Nd"):
+				page.Description = strings.TrimSpace(out)
+			}
+		}
+
+		text.WriteString(out)
+		text.WriteString("\n")
+		if !isHeading {
+			section.WriteString(out)
+			section.WriteString("\n")
+		}
+	}
+	flush()
+
+	page.Text = text.String()
+	return page
+}
+
+// splitRequest splits a roff request line into its macro name and arguments
+func splitRequest(line string) (string, string) {
+	line = strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(line, `\"`) {
+		return "", ""
+	}
+	if idx := strings.IndexAny(line, " \t"); idx != -1 {
+		return line[:idx], strings.TrimSpace(line[idx+1:])
+	}
+	return line, ""
+}
+
+// joinRoffArgs joins macro arguments, dropping the quotes that group them
+func joinRoffArgs(args string) string {
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case (c == ' ' || c == '\t') && !inQuote:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), " ") {
+				b.WriteByte(' ')
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// roffSpecialChars maps \(xx named characters to plain text
+var roffSpecialChars = map[string]string{
+	"aq": "'", "dq": "\"", "em": "--", "en": "-", "hy": "-", "mi": "-",
+	"bu": "*", "lq": "\"", "rq": "\"", "oq": "'", "cq": "'", "co": "(c)",
+	"rg": "(R)", "tm": "(TM)", "ga": "`", "ti": "~", "ha": "^", "rs": "\\",
+	"<=": "<=", ">=": ">=", "->": "->", "<-": "<-", "mu": "x", "de": "deg",
+}
+
+// unescapeRoff removes font changes and resolves common roff escapes
+func unescapeRoff(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch c := s[i]; c {
+		case 'f':
+			// \fB, \f(CW, \f[B]
+			i = skipEscapeArg(s, i+1) - 1
+		case '(':
+			if i+2 < len(s) {
+				b.WriteString(roffSpecialChars[s[i+1:i+3]])
+				i += 2
+			}
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				i = len(s)
+			} else {
+				b.WriteString(roffSpecialChars[s[i+1:i+end]])
+				i += end
+			}
+		case '*', 'n', 'm', 'g', 'k':
+			// Strings, registers and colours: drop the name
+			i = skipEscapeArg(s, i+1) - 1
+		case 's':
+			// Point size: \s0, \s+2, \s-1
+			j := i + 1
+			if j < len(s) && (s[j] == '+' || s[j] == '-') {
+				j++
+			}
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			i = j - 1
+		case '"':
+			// Comment until end of line
+			i = len(s)
+		case '-':
+			b.WriteByte('-')
+		case 'e', '\\':
+			b.WriteByte('\\')
+		case ' ', '~', '0':
+			b.WriteByte(' ')
+		case '&', '/', ',', '|', '^', ')', 'c', ':':
+			// Zero-width escapes
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// skipEscapeArg returns the index just past a one-char, (xx or [name] escape argument
+func skipEscapeArg(s string, i int) int {
+	if i >= len(s) {
+		return i
+	}
+	switch s[i] {
+	case '(':
+		return min(i+3, len(s))
+	case '[':
+		if end := strings.IndexByte(s[i:], ']'); end != -1 {
+			return i + end + 1
+		}
+		return len(s)
+	default:
+		return i + 1
+	}
+}
@@ -1,20 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 )
 
 const (
 	indexPath = ".lazyman_index"
+
+	// indexVersion is bumped whenever the document mapping changes
+	indexVersion = "3"
 )
 
+var indexVersionKey = []byte("lazyman_index_version")
+
 // ManPageDocument represents a man page document for indexing
 type ManPageDocument struct {
 	Name        string
@@ -22,6 +34,74 @@ type ManPageDocument struct {
 	Description string
 	Content     string
 	Path        string
+	Source      string // man directory the page was found under
+
+	// Section scopes, searchable with e.g. "options:" (see scopeFields)
+	Synopsis    string
+	Options     string
+	Examples    string
+	Errors      string
+	Environment string
+	Files       string
+	SeeAlso     string
+}
+
+// newManPageDocument builds an index document from a page's raw source
+func newManPageDocument(page ManPage, parsed RoffPage) ManPageDocument {
+	doc := ManPageDocument{
+		Name:        page.Name,
+		Section:     page.Section,
+		Description: page.Description,
+		Content:     parsed.Text,
+		Path:        page.Path,
+		Source:      manSource(page.Path),
+	}
+	if doc.Description == "" {
+		doc.Description = parsed.Description
+	}
+
+	for heading, text := range parsed.Sections {
+		switch scopeHeadings[heading] {
+		case "Synopsis":
+			doc.Synopsis += text + "\n"
+		case "Options":
+			doc.Options += text + "\n"
+		case "Examples":
+			doc.Examples += text + "\n"
+		case "Errors":
+			doc.Errors += text + "\n"
+		case "Environment":
+			doc.Environment += text + "\n"
+		case "Files":
+			doc.Files += text + "\n"
+		case "SeeAlso":
+			doc.SeeAlso += text + "\n"
+		}
+	}
+
+	return doc
+}
+
+// buildIndexMapping returns the index mapping for ManPageDocument
+func buildIndexMapping() *mapping.IndexMappingImpl {
+	docMapping := bleve.NewDocumentMapping()
+
+	// Sections and sources are matched exactly ("3" must not match "3p")
+	docMapping.AddFieldMappingsAt("Section", bleve.NewKeywordFieldMapping())
+	docMapping.AddFieldMappingsAt("Source", bleve.NewKeywordFieldMapping())
+
+	// Scopes duplicate parts of Content, so keep them out of the default
+	// field and don't store them
+	for _, field := range scopeFields {
+		fm := bleve.NewTextFieldMapping()
+		fm.IncludeInAll = false
+		fm.Store = false
+		docMapping.AddFieldMappingsAt(field, fm)
+	}
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = docMapping
+	return indexMapping
 }
 
 // IndexAllManPages builds or rebuilds the search index with parallel processing
@@ -29,14 +109,18 @@ func IndexAllManPages() error {
 	fmt.Println("Building search index for all man pages...")
 	fmt.Println("This may take a few minutes on first run...")
 
+	// Release the shared handle before the files underneath it go away
+	if err := CloseSearchIndex(); err != nil {
+		return fmt.Errorf("failed to close old index: %w", err)
+	}
+
 	// Remove existing index if it exists
 	if err := os.RemoveAll(indexPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove old index: %w", err)
 	}
 
 	// Create a new index
-	mapping := bleve.NewIndexMapping()
-	index, err := bleve.New(indexPath, mapping)
+	index, err := bleve.New(indexPath, buildIndexMapping())
 	if err != nil {
 		return fmt.Errorf("failed to create index: %w", err)
 	}
@@ -58,29 +142,31 @@ func IndexAllManPages() error {
 	var wg sync.WaitGroup
 	var processed atomic.Int32
 
+	// Snapshot each page's source as it's read, so later upgrades can be
+	// diffed; pages unchanged since their last snapshot aren't copied
+	started := time.Now()
+	snapshots, snapshotErr := LoadSnapshots()
+	var snapshotted atomic.Int32
+
 	// Start workers
 	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for page := range jobs {
-				// Read raw man page file directly (much faster than calling man command)
-				content, err := GetRawManContent(page.Path)
+				// Parse the raw man page file directly (much faster than calling
+				// man), reusing the text cached by earlier runs
+				parsed, err := parseManPageFile(page.Path)
 				if err != nil {
 					// Skip pages that fail to load
 					processed.Add(1)
 					continue
 				}
 
-				doc := ManPageDocument{
-					Name:        page.Name,
-					Section:     page.Section,
-					Description: page.Description,
-					Content:     content,
-					Path:        page.Path,
+				if added, err := snapshots.Record(page, page.Path); err == nil && added {
+					snapshotted.Add(1)
 				}
-
-				results <- doc
+				results <- newManPageDocument(page, parsed)
 				processed.Add(1)
 			}
 		}()
@@ -138,72 +224,264 @@ func IndexAllManPages() error {
 		}
 	}
 
+	if err := index.SetInternal(indexVersionKey, []byte(indexVersion)); err != nil {
+		return fmt.Errorf("failed to record index version: %w", err)
+	}
+
 	fmt.Printf("✓ Successfully indexed %d man pages (processed %d total)\n", count, processed.Load())
+	if snapshotErr == nil {
+		snapshotErr = snapshots.Save(started)
+	}
+	if snapshotErr != nil {
+		fmt.Printf("Warning: %v\n", snapshotErr)
+	} else if n := snapshotted.Load(); n > 0 {
+		fmt.Printf("✓ Snapshotted %d changed or new man pages\n", n)
+	}
 	return nil
 }
 
 // SearchResult represents a search result with context
 type SearchResult struct {
 	ManPage   ManPage
-	Matches   []string // Lines containing matches
+	Snippets  []Snippet // Blocks of page text around the first few matches
+	Terms     []string  // Matched words as they appear in the page, lower-cased
 	Score     float64
-	TotalHits int
+	TotalHits int // Number of matched lines in the page
+}
+
+// Snippet is a block of page text around one or more matched lines
+type Snippet struct {
+	Text  string
+	Index int // Position of the first matched line in the block among all of them, 1-based
 }
 
-// SearchIndexedManPages searches the index for the given query with fuzzy matching
-func SearchIndexedManPages(query string) ([]SearchResult, error) {
-	// Open existing index
-	index, err := bleve.Open(indexPath)
+// defaultPageSize is how many deep search hits are fetched at a time
+const defaultPageSize = 50
+
+// SearchOptions narrows a deep search
+type SearchOptions struct {
+	ExcludeSections []string // sections to leave out; "3" also excludes "3p", "3ssl", ...
+	From            int      // offset of the first hit to return
+	Size            int      // number of hits to return, defaultPageSize when 0
+
+	// Boosts multiplies the scores of the pages it names, keyed by
+	// "name(section)", reordering the hits within each boostWindow of them
+	Boosts map[string]float64
+}
+
+// boostWindow is how many deep search hits are reordered by boosts at a
+// time, so a frecent page can move up from well below the page shown
+const boostWindow = 200
+
+// SearchFacets holds hit counts per facet value for a deep search
+type SearchFacets struct {
+	Sections map[string]int // keyed by section as indexed, e.g. "3p"
+	Sources  map[string]int // keyed by man directory
+}
+
+// SearchResponse is the outcome of a deep search
+type SearchResponse struct {
+	Results []SearchResult
+	Total   uint64        // hits matching the query, across all pages
+	Facets  *SearchFacets // only computed for the first page
+}
+
+// indexOpenTimeout is how long opening the search index waits for a lazyman
+// rebuilding it
+const indexOpenTimeout = 2 * time.Second
+
+var (
+	openIndexMu sync.Mutex
+	openedIndex bleve.Index
+)
+
+// openSearchIndex returns the shared handle to the search index, opening it on first use
+func openSearchIndex() (bleve.Index, error) {
+	openIndexMu.Lock()
+	defer openIndexMu.Unlock()
+
+	if openedIndex != nil {
+		return openedIndex, nil
+	}
+
+	// Read-only takes a shared lock, so several lazymans can search at once;
+	// the timeout reports an index being rebuilt rather than waiting on it
+	index, err := bleve.OpenUsing(indexPath, map[string]interface{}{
+		"read_only":    true,
+		"bolt_timeout": indexOpenTimeout.String(),
+	})
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
 			return nil, fmt.Errorf("index not found. Run 'lazyman -S' first to build the index")
 		}
 		return nil, fmt.Errorf("failed to open index at '%s': %w", indexPath, err)
 	}
-	defer index.Close()
 
-	// Create a simple query string query (most flexible)
-	searchQuery := bleve.NewQueryStringQuery(query)
+	if version, _ := index.GetInternal(indexVersionKey); string(version) != indexVersion {
+		index.Close()
+		return nil, fmt.Errorf("search index was built by an older lazyman. Run 'lazyman -S' to rebuild it")
+	}
+
+	openedIndex = index
+	return openedIndex, nil
+}
+
+// CloseSearchIndex closes the shared index handle if it is open
+func CloseSearchIndex() error {
+	openIndexMu.Lock()
+	defer openIndexMu.Unlock()
+
+	if openedIndex == nil {
+		return nil
+	}
+	err := openedIndex.Close()
+	openedIndex = nil
+	return err
+}
+
+// SearchIndexedManPages searches the index for the given query with fuzzy
+// matching; cancelling ctx abandons the search
+func SearchIndexedManPages(ctx context.Context, query string, opts SearchOptions) (*SearchResponse, error) {
+	index, err := openSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	parsedQuery, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
 
+	// Free-text queries are treated as task phrases and widened with synonyms
+	var expansions []Expansion
+	if IsPlainQuery(query) {
+		expansions = ExpandQuery(query)
+		parsedQuery = expandIndexQuery(parsedQuery, query, expansions)
+	}
+
+	searchQuery := parsedQuery
+	if len(opts.ExcludeSections) > 0 {
+		filtered := bleve.NewBooleanQuery()
+		filtered.AddMust(parsedQuery)
+		for _, section := range opts.ExcludeSections {
+			prefix := bleve.NewPrefixQuery(section)
+			prefix.SetField("Section")
+			filtered.AddMustNot(prefix)
+		}
+		searchQuery = filtered
+	}
+
+	size := opts.Size
+	if size <= 0 {
+		size = defaultPageSize
+	}
 	searchRequest := bleve.NewSearchRequest(searchQuery)
-	searchRequest.Size = 100 // Increase to top 100 results for fuzzy matching
-	searchRequest.Highlight = bleve.NewHighlight()
+	searchRequest.From = opts.From
+	searchRequest.Size = size
+	if len(opts.Boosts) > 0 {
+		// Fetch the whole windows the page falls in, so boosted pages can
+		// rise into it from further down
+		searchRequest.From = opts.From / boostWindow * boostWindow
+		searchRequest.Size = (opts.From+size+boostWindow-1)/boostWindow*boostWindow - searchRequest.From
+	}
+	searchRequest.IncludeLocations = true
 	searchRequest.Fields = []string{"Name", "Section", "Description", "Content"}
 
 	// Execute search
-	searchResults, err := index.Search(searchRequest)
+	searchResults, err := index.SearchInContext(ctx, searchRequest)
 	if err != nil {
 		return nil, fmt.Errorf("search execution failed (query: '%s'): %w", query, err)
 	}
 
+	response := &SearchResponse{Total: searchResults.Total}
+
+	// Facets always count the unfiltered query, so excluded sections still
+	// show how many hits they would add back
+	if opts.From == 0 {
+		facetRequest := bleve.NewSearchRequest(parsedQuery)
+		facetRequest.Size = 0
+		facetRequest.AddFacet("Section", bleve.NewFacetRequest("Section", 50))
+		facetRequest.AddFacet("Source", bleve.NewFacetRequest("Source", 10))
+		facetResults, err := index.SearchInContext(ctx, facetRequest)
+		if err != nil {
+			return nil, fmt.Errorf("facet search failed (query: '%s'): %w", query, err)
+		}
+
+		response.Facets = &SearchFacets{
+			Sections: facetCounts(facetResults, "Section"),
+			Sources:  facetCounts(facetResults, "Source"),
+		}
+	}
+
+	hits := searchResults.Hits
+	if len(opts.Boosts) > 0 {
+		hits = boostHits(hits, opts.Boosts, searchRequest.From)
+		// Keep the page asked for
+		skip := opts.From - searchRequest.From
+		hits = hits[min(skip, len(hits)):min(skip+size, len(hits))]
+	}
+
 	// Convert results
-	results := make([]SearchResult, 0, len(searchResults.Hits))
-	for _, hit := range searchResults.Hits {
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
 		// Extract name and section from document ID "name(section)"
 		docID := hit.ID
 		name, section := parseDocID(docID)
 
-		// Extract matching lines from content
-		var matches []string
-		if content, ok := hit.Fields["Content"].(string); ok {
-			matches = extractMatchingLines(content, query, 3)
-		}
-
 		result := SearchResult{
 			ManPage: ManPage{
 				Name:        name,
 				Section:     section,
 				Description: getFieldString(hit.Fields, "Description"),
 			},
-			Matches:   matches,
-			Score:     hit.Score,
-			TotalHits: len(matches),
+			Score: hit.Score,
+		}
+		if expansion, ok := expansionFor(name, expansions); ok {
+			result.ManPage.Via = expansion.Phrase
 		}
 
+		// Build snippets from where bleve found the query terms
+		content := getFieldString(hit.Fields, "Content")
+		result.Snippets, result.Terms, result.TotalHits = buildSnippets(content, hit.Locations, 2, 3)
+
 		results = append(results, result)
 	}
+	response.Results = results
+
+	return response, nil
+}
 
-	return results, nil
+// boostHits multiplies the scores of hits by their boosts and re-sorts each
+// boostWindow of them, from being the offset of the first hit. Windows are
+// fixed so that paging through the hits never shows one twice.
+func boostHits(hits search.DocumentMatchCollection, boosts map[string]float64, from int) search.DocumentMatchCollection {
+	for _, hit := range hits {
+		if boost, ok := boosts[hit.ID]; ok {
+			hit.Score *= boost
+		}
+	}
+	for start := 0; start < len(hits); {
+		end := min(start+boostWindow-(from+start)%boostWindow, len(hits))
+		window := hits[start:end]
+		sort.SliceStable(window, func(i, j int) bool {
+			return window[i].Score > window[j].Score
+		})
+		start = end
+	}
+	return hits
+}
+
+// facetCounts flattens a term facet into a map of term -> hit count
+func facetCounts(results *bleve.SearchResult, name string) map[string]int {
+	counts := make(map[string]int)
+	facet, ok := results.Facets[name]
+	if !ok {
+		return counts
+	}
+	for _, term := range facet.Terms.Terms() {
+		counts[term.Term] = term.Count
+	}
+	return counts
 }
 
 // parseDocID extracts name and section from "name(section)" format
@@ -224,42 +502,99 @@ func getFieldString(fields map[string]interface{}, key string) string {
 	return ""
 }
 
-// extractMatchingLines finds lines containing the query and returns them with context
-func extractMatchingLines(content, query string, contextLines int) []string {
-	lines := strings.Split(content, "\n")
-	queryLower := strings.ToLower(query)
-	matches := []string{}
-	matchedLines := make(map[int]bool)
-
-	// Find all matching lines
-	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), queryLower) {
-			matchedLines[i] = true
+// snippetSkipFields are matched as a whole rather than in the page text, so
+// their terms (e.g. "7" from section:7) aren't looked for in it
+var snippetSkipFields = map[string]bool{"Name": true, "Section": true, "Source": true}
+
+// buildSnippets groups the matched term locations in content into snippets
+// with contextLines of surrounding text, returning at most maxSnippets of
+// them along with the matched words and the number of matched lines
+func buildSnippets(content string, locations search.FieldTermLocationMap, contextLines, maxSnippets int) ([]Snippet, []string, int) {
+	contentLower := strings.ToLower(content)
+	termSet := make(map[string]bool)
+	var offsets []int
+
+	for field, terms := range locations {
+		if snippetSkipFields[field] {
+			continue
+		}
+		for term, locs := range terms {
+			if field != "Content" {
+				// Offsets point into a field that isn't stored (e.g. a
+				// section scope), so look the term up in the full text
+				termSet[term] = true
+				continue
+			}
+			for _, loc := range locs {
+				if int(loc.End) > len(content) || loc.Start >= loc.End {
+					continue
+				}
+				offsets = append(offsets, int(loc.Start))
+				termSet[contentLower[loc.Start:loc.End]] = true
+			}
 		}
 	}
 
-	// Extract matches with context
-	for lineNum := range matchedLines {
-		start := lineNum - contextLines
-		if start < 0 {
-			start = 0
-		}
-		end := lineNum + contextLines + 1
-		if end > len(lines) {
-			end = len(lines)
+	if len(offsets) == 0 {
+		for term := range termSet {
+			// Whole words only, so "at" doesn't match inside "format"
+			pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(term) + `\b`)
+			for _, loc := range pattern.FindAllStringIndex(contentLower, -1) {
+				offsets = append(offsets, loc[0])
+			}
 		}
+	}
+
+	terms := make([]string, 0, len(termSet))
+	for term := range termSet {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
 
-		// Build context block
-		contextBlock := strings.Join(lines[start:end], "\n")
-		matches = append(matches, strings.TrimSpace(contextBlock))
+	if len(offsets) == 0 {
+		return nil, terms, 0
+	}
 
-		// Limit to top 3 matches per document
-		if len(matches) >= 3 {
-			break
+	// Map byte offsets to line numbers
+	lines := strings.Split(content, "\n")
+	lineStarts := make([]int, len(lines))
+	pos := 0
+	for i, line := range lines {
+		lineStarts[i] = pos
+		pos += len(line) + 1
+	}
+
+	matchedSet := make(map[int]bool)
+	for _, offset := range offsets {
+		matchedSet[sort.SearchInts(lineStarts, offset+1)-1] = true
+	}
+	matchedLines := make([]int, 0, len(matchedSet))
+	for line := range matchedSet {
+		matchedLines = append(matchedLines, line)
+	}
+	sort.Ints(matchedLines)
+
+	// Merge matches whose context overlaps into one snippet
+	var snippets []Snippet
+	for i := 0; i < len(matchedLines) && len(snippets) < maxSnippets; {
+		first := matchedLines[i]
+		last := first
+		j := i + 1
+		for j < len(matchedLines) && matchedLines[j]-last <= 2*contextLines {
+			last = matchedLines[j]
+			j++
 		}
+
+		start := max(first-contextLines, 0)
+		end := min(last+contextLines+1, len(lines))
+		snippets = append(snippets, Snippet{
+			Text:  strings.TrimSpace(strings.Join(lines[start:end], "\n")),
+			Index: i + 1,
+		})
+		i = j
 	}
 
-	return matches
+	return snippets, terms, len(matchedLines)
 }
 
 // IndexExists checks if the search index exists
@@ -270,6 +605,16 @@ func IndexExists() bool {
 	return true
 }
 
+// manSource returns the man directory (e.g. /usr/share/man) a page file lives under
+func manSource(path string) string {
+	for _, root := range getManPaths() {
+		if strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return filepath.Dir(filepath.Dir(path))
+}
+
 // GetIndexPath returns the absolute path to the index
 func GetIndexPath() (string, error) {
 	absPath, err := filepath.Abs(indexPath)
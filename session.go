@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// sessionTab is an open tab as saved between launches: the position read
+// to and the pages behind and ahead of it in the tab's history
+type sessionTab struct {
+	Position Bookmark   `json:"position"`
+	Back     []Bookmark `json:"back,omitempty"`
+	Forward  []Bookmark `json:"forward,omitempty"`
+}
+
+// sessionFile is the layout of session.json
+type sessionFile struct {
+	Version int          `json:"version"`
+	Active  int          `json:"active"`
+	Tabs    []sessionTab `json:"tabs"`
+}
+
+// Session is the tabs left open when lazyman last quit, which can be
+// restored on the next launch
+type Session struct {
+	path   string
+	Active int
+	Tabs   []sessionTab
+}
+
+// LoadSession reads the saved session, which has no tabs if there's none
+func LoadSession() (*Session, error) {
+	s := &Session{}
+	dir, err := dataDir()
+	if err != nil {
+		return s, fmt.Errorf("no data directory for the session: %w", err)
+	}
+
+	path := filepath.Join(dir, "session.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.path = path
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read session: %w", err)
+	}
+	var file sessionFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return s, fmt.Errorf("invalid session file %s: %w", path, err)
+	}
+	s.path = path
+	s.Tabs = file.Tabs
+	s.Active = min(max(file.Active, 0), max(len(file.Tabs)-1, 0))
+	return s, nil
+}
+
+// Save replaces the saved session with tabs
+func (s *Session) Save(active int, tabs []sessionTab) error {
+	if s.path == "" {
+		return errors.New("session can't be saved: no usable session file")
+	}
+	s.Active, s.Tabs = active, tabs
+
+	data, err := json.MarshalIndent(sessionFile{Version: 1, Active: active, Tabs: tabs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	if err := writeFileAtomic(s.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
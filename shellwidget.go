@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Scripts printed by "lazyman --shell-init". Each binds Alt+H to a widget that
+// runs lazyman on the command line being typed. lazyman prints the cursor
+// position on its first line and the new command line after it, and exits
+// with an error if nothing was picked.
+const (
+	bashInitScript = `# lazyman shell integration for bash: Alt+H looks up the command being typed
+__lazyman_widget() {
+    local out
+    out=$(lazyman --widget --line "$READLINE_LINE" --point "$READLINE_POINT" </dev/tty) || return
+    READLINE_LINE=${out#*$'\n'}
+    READLINE_POINT=${out%%$'\n'*}
+}
+bind -x '"\eh": __lazyman_widget'
+`
+
+	zshInitScript = `# lazyman shell integration for zsh: Alt+H looks up the command being typed
+lazyman-widget() {
+    local out
+    if out=$(lazyman --widget --line "$BUFFER" --point "$CURSOR" </dev/tty); then
+        BUFFER=${out#*$'\n'}
+        CURSOR=${out%%$'\n'*}
+    fi
+    zle reset-prompt
+}
+zle -N lazyman-widget
+bindkey '^[h' lazyman-widget
+`
+
+	fishInitScript = `# lazyman shell integration for fish: Alt+H looks up the command being typed
+function __lazyman_widget
+    set -l out (lazyman --widget --line (commandline | string collect) --point (commandline -C) </dev/tty | string collect)
+    if test -n "$out"
+        set -l parts (string split -m 1 \n -- $out)
+        commandline -r -- $parts[2]
+        commandline -C -- $parts[1]
+    end
+    commandline -f repaint
+end
+bind \eh __lazyman_widget
+`
+)
+
+// shellInitScript returns the widget script for a shell
+func shellInitScript(shell string) (string, error) {
+	switch filepath.Base(shell) {
+	case "bash":
+		return bashInitScript, nil
+	case "zsh":
+		return zshInitScript, nil
+	case "fish":
+		return fishInitScript, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (expected bash, zsh or fish)", shell)
+}
+
+// commandWrappers run the command after them, so the page wanted is that
+// command's
+var commandWrappers = []string{"sudo", "doas", "env", "time", "nohup", "exec", "command", "nice", "xargs", "watch"}
+
+// shellWord is a word of a command line, at runes [start, end)
+type shellWord struct {
+	text       string
+	start, end int
+}
+
+// shellWidget is the command line a shell widget ran lazyman from
+type shellWidget struct {
+	line  []rune
+	point int      // cursor position in line
+	pages []string // pages to try for the command, most specific first
+	word  int      // index in words of the word under the cursor; -1 if none
+	words []shellWord
+	flag  string // the flag under the cursor, e.g. "--all"; "" if none
+}
+
+// newShellWidget works out the command being typed at point in line, and the
+// flag under the cursor. A point out of range is the end of the line.
+func newShellWidget(line string, point int) shellWidget {
+	w := shellWidget{line: []rune(line), point: point, word: -1}
+	if w.point < 0 || w.point > len(w.line) {
+		w.point = len(w.line)
+	}
+	w.words = commandWords(w.line, w.point)
+
+	// Skip variable assignments and wrappers like sudo, with their flags
+	i := 0
+	for i < len(w.words) {
+		text := w.words[i].text
+		switch {
+		case isAssignment(text):
+			i++
+			continue
+		case slices.Contains(commandWrappers, text):
+			i++
+			for i < len(w.words) && strings.HasPrefix(w.words[i].text, "-") {
+				i++
+			}
+			continue
+		}
+		break
+	}
+	if i < len(w.words) {
+		name := filepath.Base(w.words[i].text)
+		// Subcommands have their own pages, e.g. git-commit(1)
+		if i+1 < len(w.words) && isSubcommand(w.words[i+1].text) {
+			w.pages = append(w.pages, name+"-"+w.words[i+1].text)
+		}
+		w.pages = append(w.pages, name)
+	}
+
+	for j, word := range w.words {
+		if word.start <= w.point && w.point <= word.end {
+			w.word = j
+			if strings.HasPrefix(word.text, "-") && word.text != "-" && word.text != "--" {
+				w.flag, _, _ = strings.Cut(word.text, "=")
+			}
+			break
+		}
+	}
+	return w
+}
+
+// commandWords splits the command at point in line into words. Quotes and
+// backslashes are honored roughly; pipes, ;, & and parentheses separate
+// commands.
+func commandWords(line []rune, point int) []shellWord {
+	var words []shellWord
+	var word []rune
+	start := -1
+	var quote rune
+	flush := func(end int) {
+		if start >= 0 {
+			words = append(words, shellWord{text: string(word), start: start, end: end})
+		}
+		word, start = nil, -1
+	}
+
+	for i := 0; i < len(line); i++ {
+		r := line[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word = append(word, r)
+			}
+			continue
+		case r == '\\' && i+1 < len(line):
+			if start < 0 {
+				start = i
+			}
+			i++
+			word = append(word, line[i])
+			continue
+		case r == '\'' || r == '"':
+			if start < 0 {
+				start = i
+			}
+			quote = r
+			continue
+		case unicode.IsSpace(r):
+			flush(i)
+			continue
+		case strings.ContainsRune("|&;()", r):
+			flush(i)
+			if i >= point {
+				return words
+			}
+			// A later command is the one at the cursor
+			words = nil
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		word = append(word, r)
+	}
+	flush(len(line))
+	return words
+}
+
+// isSubcommand reports whether a word could be a subcommand, like commit in
+// git commit, rather than an argument
+func isSubcommand(word string) bool {
+	if word == "" || !unicode.IsLetter(rune(word[0])) {
+		return false
+	}
+	return strings.IndexFunc(word, func(r rune) bool {
+		return r != '-' && r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) < 0
+}
+
+// isAssignment reports whether a word sets a variable, as in FOO=bar cmd
+func isAssignment(word string) bool {
+	name, _, found := strings.Cut(word, "=")
+	if !found || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// insertFlag returns the command line with flag completing the flag under
+// the cursor, or inserted after the word under the cursor, and the cursor
+// after it
+func (w shellWidget) insertFlag(flag string) (string, int) {
+	point := w.point
+	if w.word >= 0 {
+		word := w.words[w.word]
+		if w.flag != "" && strings.HasPrefix(flag, w.flag) {
+			line := string(w.line[:word.start]) + flag + string(w.line[word.end:])
+			return line, word.start + len([]rune(flag))
+		}
+		point = word.end
+	}
+	before, after := string(w.line[:point]), string(w.line[point:])
+	if before != "" && !strings.HasSuffix(before, " ") {
+		flag = " " + flag
+	}
+	if !strings.HasPrefix(after, " ") {
+		flag += " "
+	}
+	return before + flag + after, point + len([]rune(flag))
+}
+
+// optionEntryFor returns the line of the option entry documenting flag, or -1
+// if there's none. A cluster of short flags like -xvf is looked up by its
+// first.
+func optionEntryFor(lines []string, flag string) int {
+	candidates := []string{flag}
+	if !strings.HasPrefix(flag, "--") && len(flag) > 2 {
+		candidates = append(candidates, flag[:2])
+	}
+	for _, candidate := range candidates {
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "-") && slices.Contains(optionFlags(trimmed), candidate) {
+				return i
+			}
+		}
+	}
+	return -1
+}
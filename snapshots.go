@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// snapshotsPerPage is how many versions of a page are kept; the oldest are
+// deleted beyond it
+const snapshotsPerPage = 20
+
+// Snapshot is a saved version of a page's source
+type Snapshot struct {
+	Taken       time.Time `json:"taken"`
+	Hash        string    `json:"hash"`         // sha256 of the source
+	Source      string    `json:"source"`       // the page's source file
+	SourceMTime time.Time `json:"source_mtime"` // its modification time when last checked
+	File        string    `json:"file"`         // the copy, relative to the snapshot directory
+}
+
+// snapshotManifest is the layout of snapshots/manifest.json
+type snapshotManifest struct {
+	Version int                   `json:"version"`
+	LastRun time.Time             `json:"last_run"` // when the latest run over every page started
+	Pages   map[string][]Snapshot `json:"pages"`    // keyed by "name(section)", oldest first
+}
+
+// SnapshotStore keeps versions of page sources so pages can be compared with
+// how they were before an upgrade. Each version is a gzipped copy of the
+// source, recorded only when it differs from the one before.
+type SnapshotStore struct {
+	dir string
+
+	mu       sync.Mutex
+	manifest snapshotManifest
+	changed  map[string]bool // pages recorded since loading, keyed like manifest.Pages
+}
+
+// LoadSnapshots reads the snapshot manifest; with no snapshot directory
+// every operation fails
+func LoadSnapshots() (*SnapshotStore, error) {
+	s := &SnapshotStore{
+		manifest: snapshotManifest{Pages: make(map[string][]Snapshot)},
+		changed:  make(map[string]bool),
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return s, fmt.Errorf("no data directory for snapshots: %w", err)
+	}
+
+	dir = filepath.Join(dir, "snapshots")
+	manifest, err := readSnapshotManifest(dir)
+	if err != nil {
+		return s, err
+	}
+	s.dir = dir
+	s.manifest = manifest
+	return s, nil
+}
+
+// readSnapshotManifest reads the manifest in dir, which is empty if there's
+// none yet
+func readSnapshotManifest(dir string) (snapshotManifest, error) {
+	manifest := snapshotManifest{Pages: make(map[string][]Snapshot)}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid snapshot manifest in %s: %w", dir, err)
+	}
+	if manifest.Pages == nil {
+		manifest.Pages = make(map[string][]Snapshot)
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest. runStarted is when a run over every page began,
+// or zero if only some pages were snapshotted. Snapshots saved by another
+// lazyman since the manifest was loaded are merged in, not lost.
+func (s *SnapshotStore) Save(runStarted time.Time) error {
+	if s.dir == "" {
+		return errors.New("snapshots can't be saved: no usable snapshot directory")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(filepath.Join(s.dir, "manifest.lock"), dataLockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	defer unlock()
+
+	saved, err := readSnapshotManifest(s.dir)
+	if err != nil {
+		return err
+	}
+	s.merge(saved)
+	if !runStarted.IsZero() && runStarted.After(s.manifest.LastRun) {
+		s.manifest.LastRun = runStarted
+	}
+	s.manifest.Version = 1
+
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, "manifest.json"), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	s.changed = make(map[string]bool)
+	return nil
+}
+
+// merge takes the pages in saved that weren't recorded here, and adds the
+// versions of those that were which another lazyman saved. Call with s.mu
+// held.
+func (s *SnapshotStore) merge(saved snapshotManifest) {
+	if saved.LastRun.After(s.manifest.LastRun) {
+		s.manifest.LastRun = saved.LastRun
+	}
+	for key, theirs := range saved.Pages {
+		if !s.changed[key] {
+			s.manifest.Pages[key] = theirs
+			continue
+		}
+		ours := s.manifest.Pages[key]
+		files := make(map[string]bool, len(ours))
+		for _, version := range ours {
+			files[version.File] = true
+		}
+		for _, version := range theirs {
+			if !files[version.File] {
+				ours = append(ours, version)
+			}
+		}
+		sort.SliceStable(ours, func(i, j int) bool {
+			return ours[i].Taken.Before(ours[j].Taken)
+		})
+		s.manifest.Pages[key] = s.trimVersions(ours)
+	}
+}
+
+// trimVersions deletes the oldest versions beyond snapshotsPerPage
+func (s *SnapshotStore) trimVersions(versions []Snapshot) []Snapshot {
+	if len(versions) <= snapshotsPerPage {
+		return versions
+	}
+	for _, old := range versions[:len(versions)-snapshotsPerPage] {
+		os.Remove(filepath.Join(s.dir, old.File))
+	}
+	return versions[len(versions)-snapshotsPerPage:]
+}
+
+// Record snapshots page's source file at path if it changed since its last
+// snapshot, reporting whether a new version was saved. Call Save afterwards
+// to keep it.
+func (s *SnapshotStore) Record(page ManPage, path string) (bool, error) {
+	if s.dir == "" {
+		return false, errors.New("snapshots can't be saved: no usable snapshot directory")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to snapshot %s(%s): %w", page.Name, page.Section, err)
+	}
+	raw, err := GetRawManContent(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to snapshot %s(%s): %w", page.Name, page.Section, err)
+	}
+	sum := sha256.Sum256([]byte(raw))
+	hash := hex.EncodeToString(sum[:])
+
+	key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+	s.mu.Lock()
+	versions := s.manifest.Pages[key]
+	if n := len(versions); n > 0 && versions[n-1].Hash == hash {
+		// Unchanged; only its file was touched
+		versions[n-1].Source = path
+		versions[n-1].SourceMTime = info.ModTime()
+		s.changed[key] = true
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.mu.Unlock()
+
+	now := time.Now()
+	file := filepath.Join(page.Name+"."+page.Section, fmt.Sprintf("%d.gz", now.UnixNano()))
+	if err := writeGzipFile(filepath.Join(s.dir, file), raw); err != nil {
+		return false, fmt.Errorf("failed to snapshot %s(%s): %w", page.Name, page.Section, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions = append(s.manifest.Pages[key], Snapshot{
+		Taken:       now,
+		Hash:        hash,
+		Source:      path,
+		SourceMTime: info.ModTime(),
+		File:        file,
+	})
+	s.manifest.Pages[key] = s.trimVersions(versions)
+	s.changed[key] = true
+	return true, nil
+}
+
+// Versions returns the snapshots of name(section), oldest first
+func (s *SnapshotStore) Versions(name, section string) []Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Snapshot(nil), s.manifest.Pages[fmt.Sprintf("%s(%s)", name, section)]...)
+}
+
+// Source returns the page source saved in a snapshot
+func (s *SnapshotStore) Source(snapshot Snapshot) (string, error) {
+	content, err := readGzippedFile(filepath.Join(s.dir, snapshot.File))
+	if err != nil {
+		return "", fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return content, nil
+}
+
+// Changed returns the "name(section)" of pages that have changed since
+// their last snapshot, or whose last snapshot recorded a change in the
+// latest run over every page
+func (s *SnapshotStore) Changed() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := make(map[string]bool)
+	for key, versions := range s.manifest.Pages {
+		latest := versions[len(versions)-1]
+		if len(versions) > 1 && !s.manifest.LastRun.IsZero() && !latest.Taken.Before(s.manifest.LastRun) {
+			changed[key] = true
+			continue
+		}
+		if info, err := os.Stat(latest.Source); err == nil && !info.ModTime().Equal(latest.SourceMTime) {
+			changed[key] = true
+		}
+	}
+	return changed
+}
+
+// writeGzipFile writes content compressed to path, creating its directory
+func writeGzipFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, content); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
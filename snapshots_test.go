@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testVersions returns a page's versions taken at the given minutes, with
+// files named after them
+func testVersions(minutes ...int) []Snapshot {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := make([]Snapshot, 0, len(minutes))
+	for _, minute := range minutes {
+		versions = append(versions, Snapshot{
+			Taken: base.Add(time.Duration(minute) * time.Minute),
+			File:  fmt.Sprintf("tar.1/%d.gz", minute),
+		})
+	}
+	return versions
+}
+
+// versionMinutes returns the minutes testVersions took versions at
+func versionMinutes(versions []Snapshot) []int {
+	var minutes []int
+	for _, version := range versions {
+		var minute int
+		fmt.Sscanf(version.File, "tar.1/%d.gz", &minute)
+		minutes = append(minutes, minute)
+	}
+	return minutes
+}
+
+func TestSnapshotStoreMerge(t *testing.T) {
+	many := make([]int, snapshotsPerPage)
+	for i := range many {
+		many[i] = i * 2
+	}
+	recent := many[3:]
+
+	tests := []struct {
+		name    string
+		ours    []Snapshot
+		theirs  []Snapshot
+		changed bool
+		want    []int
+	}{
+		{"unrecorded page takes theirs", testVersions(1), testVersions(1, 2), false, []int{1, 2}},
+		{"unrecorded page keeps theirs when they trimmed", testVersions(1, 2), testVersions(2), false, []int{2}},
+		{"recorded page only here", testVersions(1, 3), nil, true, []int{1, 3}},
+		{"recorded page gains their versions", testVersions(1, 3), testVersions(1, 2), true, []int{1, 2, 3}},
+		{"same versions aren't repeated", testVersions(1, 2), testVersions(1, 2), true, []int{1, 2}},
+		{"merged versions are trimmed", testVersions(many...), testVersions(1, 3, 5), true, append([]int{3, 4, 5}, recent...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SnapshotStore{
+				dir:      t.TempDir(),
+				manifest: snapshotManifest{Pages: map[string][]Snapshot{"tar(1)": tt.ours}},
+				changed:  map[string]bool{"tar(1)": tt.changed},
+			}
+			saved := snapshotManifest{Pages: make(map[string][]Snapshot)}
+			if tt.theirs != nil {
+				saved.Pages["tar(1)"] = tt.theirs
+			}
+			s.merge(saved)
+			if got := versionMinutes(s.manifest.Pages["tar(1)"]); !slices.Equal(got, tt.want) {
+				t.Errorf("merged versions %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotStoreSaveConcurrent(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	sources := t.TempDir()
+	writeSource := func(name, content string) string {
+		path := filepath.Join(sources, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tar := ManPage{Name: "tar", Section: "1"}
+	gzip := ManPage{Name: "gzip", Section: "1"}
+	ls := ManPage{Name: "ls", Section: "1"}
+
+	// Both instances load the same manifest, with one version of ls
+	first, err := LoadSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Record(ls, writeSource("ls.1", "ls v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Save(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := LoadSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each records pages, and both record a new version of tar
+	tarPath := writeSource("tar.1", "tar v1")
+	if _, err := a.Record(tar, tarPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Record(gzip, writeSource("gzip.1", "gzip v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tarPath, []byte("tar v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Record(tar, tarPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Save(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Save(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := LoadSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		page ManPage
+		want []string
+	}{
+		{ls, []string{"ls v1"}},
+		{gzip, []string{"gzip v1"}},
+		{tar, []string{"tar v1", "tar v2"}},
+	} {
+		var got []string
+		for _, version := range saved.Versions(tt.page.Name, tt.page.Section) {
+			source, err := saved.Source(version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, source)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s(%s) versions %q, want %q", tt.page.Name, tt.page.Section, got, tt.want)
+		}
+	}
+}
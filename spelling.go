@@ -0,0 +1,248 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// bkNode is a node of a BK-tree; children are keyed by their distance to term
+type bkNode struct {
+	term     string
+	weight   uint64
+	children map[int]*bkNode
+}
+
+// BKTree indexes terms by edit distance so near matches can be found without
+// comparing the query against every term
+type BKTree struct {
+	root *bkNode
+	size int
+}
+
+// bkMatch is a term found within the requested distance of a query
+type bkMatch struct {
+	term     string
+	distance int
+	weight   uint64
+}
+
+// Insert adds a lower-cased term with a weight (e.g. document frequency)
+func (t *BKTree) Insert(term string, weight uint64) {
+	term = strings.ToLower(term)
+	if t.root == nil {
+		t.root = &bkNode{term: term, weight: weight}
+		t.size++
+		return
+	}
+
+	node := t.root
+	for {
+		d := levenshteinDistance(term, node.term)
+		if d == 0 {
+			node.weight += weight
+			return
+		}
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{term: term, weight: weight}
+			t.size++
+			return
+		}
+		node = child
+	}
+}
+
+// Search returns all terms within maxDistance edits of query, closest and
+// heaviest first
+func (t *BKTree) Search(query string, maxDistance int) []bkMatch {
+	if t.root == nil {
+		return nil
+	}
+	query = strings.ToLower(query)
+
+	var matches []bkMatch
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := levenshteinDistance(query, node.term)
+		if d <= maxDistance {
+			matches = append(matches, bkMatch{term: node.term, distance: d, weight: node.weight})
+		}
+		// Triangle inequality: only children within [d-max, d+max] can match
+		for childDist, child := range node.children {
+			if childDist >= d-maxDistance && childDist <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		if matches[i].weight != matches[j].weight {
+			return matches[i].weight > matches[j].weight
+		}
+		return matches[i].term < matches[j].term
+	})
+	return matches
+}
+
+// Speller suggests corrections from page names and, when the search index
+// exists, from the terms used in page content
+type Speller struct {
+	names      *BKTree
+	pages      map[string][]ManPage // lower-cased name -> pages with that name
+	terms      *BKTree
+	knownTerms map[string]bool
+}
+
+// maxEditDistance scales the allowed edits with the word length
+func maxEditDistance(word string) int {
+	switch n := len(word); {
+	case n <= 4:
+		return 1
+	case n <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// NewSpeller builds a speller over the given pages, and over the search
+// index's content terms too when withIndexTerms is set and the index is
+// available
+func NewSpeller(pages []ManPage, withIndexTerms bool) *Speller {
+	s := &Speller{
+		names:      &BKTree{},
+		pages:      make(map[string][]ManPage),
+		terms:      &BKTree{},
+		knownTerms: make(map[string]bool),
+	}
+	for _, page := range pages {
+		key := strings.ToLower(page.Name)
+		if _, ok := s.pages[key]; !ok {
+			s.names.Insert(key, 1)
+		}
+		s.pages[key] = append(s.pages[key], page)
+	}
+
+	if withIndexTerms && IndexExists() {
+		if index, err := openSearchIndex(); err == nil {
+			s.addIndexTerms(index)
+		}
+	}
+	return s
+}
+
+// addIndexTerms adds the content terms of the index, skipping ones too rare
+// or short to be worth suggesting
+func (s *Speller) addIndexTerms(index bleve.Index) {
+	dict, err := index.FieldDict("Content")
+	if err != nil {
+		return
+	}
+	defer dict.Close()
+
+	for {
+		entry, err := dict.Next()
+		if err != nil || entry == nil {
+			return
+		}
+		s.knownTerms[entry.Term] = true
+		if entry.Count < 2 || len(entry.Term) < 3 || strings.Trim(entry.Term, "abcdefghijklmnopqrstuvwxyz_") != "" {
+			continue
+		}
+		s.terms.Insert(entry.Term, entry.Count)
+	}
+}
+
+// SuggestPages returns up to limit pages whose names are close to query,
+// ranked by edit distance with exact substring matches first
+func (s *Speller) SuggestPages(query string, limit int) []ManPage {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	type scored struct {
+		name  string
+		score int
+	}
+	best := make(map[string]int)
+	for _, match := range s.names.Search(query, maxEditDistance(query)) {
+		best[match.term] = match.distance
+	}
+	// Names containing the query rank ahead of misspellings
+	for name := range s.pages {
+		if strings.Contains(name, query) && name != query {
+			best[name] = -1
+		}
+	}
+
+	ranked := make([]scored, 0, len(best))
+	for name, score := range best {
+		ranked = append(ranked, scored{name, score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score < ranked[j].score
+		}
+		if len(ranked[i].name) != len(ranked[j].name) {
+			return len(ranked[i].name) < len(ranked[j].name)
+		}
+		return ranked[i].name < ranked[j].name
+	})
+
+	var result []ManPage
+	for _, r := range ranked {
+		for _, page := range s.pages[r.name] {
+			if len(result) == limit {
+				return result
+			}
+			result = append(result, page)
+		}
+	}
+	return result
+}
+
+// CorrectQuery replaces words of a deep search query that don't occur in
+// the index with the closest common term, returning "" if nothing changed
+func (s *Speller) CorrectQuery(query string) string {
+	if len(s.knownTerms) == 0 {
+		return ""
+	}
+
+	words := strings.Fields(query)
+	changed := false
+	for i, word := range words {
+		prefix, value := "", word
+		if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+			prefix, value = value[:1], value[1:]
+		}
+		if field, rest, ok := strings.Cut(value, ":"); ok {
+			prefix += field + ":"
+			value = rest
+		}
+		if value == "" || strings.ContainsAny(value, "\"*?~\\") || s.knownTerms[strings.ToLower(value)] {
+			continue
+		}
+
+		if matches := s.terms.Search(value, maxEditDistance(value)); len(matches) > 0 {
+			words[i] = prefix + matches[0].term
+			changed = true
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+//go:embed synonyms.txt
+var defaultSynonyms string
+
+// synonymFillerWords are ignored when matching task phrases
+var synonymFillerWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "my": true,
+	"for": true, "in": true, "on": true, "with": true, "how": true, "do": true,
+	"i": true, "is": true, "and": true, "or": true, "all": true, "from": true,
+	"what": true, "which": true, "show": true, "get": true, "into": true,
+}
+
+// Synonym maps a task phrase to the terms that handle it
+type Synonym struct {
+	Phrase string
+	Words  []string // phrase words without filler
+	Terms  []string
+}
+
+// Expansion is a term added to a query because a task phrase matched it
+type Expansion struct {
+	Term   string
+	Phrase string
+}
+
+var (
+	synonymsOnce sync.Once
+	synonyms     []Synonym
+)
+
+// loadSynonyms returns the shipped synonyms merged with the user's file
+func loadSynonyms() []Synonym {
+	synonymsOnce.Do(func() {
+		synonyms = parseSynonyms(defaultSynonyms)
+		if dir, err := configDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(dir, "synonyms.txt")); err == nil {
+				synonyms = append(synonyms, parseSynonyms(string(data))...)
+			}
+		}
+	})
+	return synonyms
+}
+
+// parseSynonyms parses "phrase = term, term" lines, skipping comments and malformed lines
+func parseSynonyms(data string) []Synonym {
+	var result []Synonym
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		phrase, terms, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		s := Synonym{
+			Phrase: strings.TrimSpace(phrase),
+			Words:  synonymWords(phrase),
+		}
+		for _, term := range strings.Split(terms, ",") {
+			if term = strings.TrimSpace(term); term != "" {
+				s.Terms = append(s.Terms, term)
+			}
+		}
+		if len(s.Words) > 0 && len(s.Terms) > 0 {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// synonymWords lower-cases text and splits it into words, dropping filler
+func synonymWords(text string) []string {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		word = strings.Trim(word, ".,;:!?\"'")
+		if word != "" && !synonymFillerWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// ExpandQuery returns the synonym terms for every task phrase contained in
+// query, most specific phrase first
+func ExpandQuery(query string) []Expansion {
+	queryWords := make(map[string]bool)
+	for _, word := range synonymWords(query) {
+		queryWords[word] = true
+	}
+	if len(queryWords) == 0 {
+		return nil
+	}
+
+	var matched []Synonym
+	for _, s := range loadSynonyms() {
+		all := true
+		for _, word := range s.Words {
+			if !queryWords[word] {
+				all = false
+				break
+			}
+		}
+		if all {
+			matched = append(matched, s)
+		}
+	}
+
+	// Longer phrases are more specific, so their terms come first
+	sort.SliceStable(matched, func(i, j int) bool {
+		return len(matched[i].Words) > len(matched[j].Words)
+	})
+
+	var expansions []Expansion
+	seen := make(map[string]bool)
+	for _, s := range matched {
+		for _, term := range s.Terms {
+			if seen[term] || queryWords[strings.ToLower(term)] {
+				continue
+			}
+			seen[term] = true
+			expansions = append(expansions, Expansion{Term: term, Phrase: s.Phrase})
+		}
+	}
+	return expansions
+}
+
+// expandIndexQuery widens a free-text deep search with the synonym
+// expansions' page names and with NAME-line descriptions containing every
+// word of the query
+func expandIndexQuery(parsed query.Query, input string, expansions []Expansion) query.Query {
+	expanded := bleve.NewDisjunctionQuery(parsed)
+
+	desc := bleve.NewMatchQuery(input)
+	desc.SetField("Description")
+	desc.SetOperator(query.MatchQueryOperatorAnd)
+	desc.SetBoost(2)
+	expanded.AddQuery(desc)
+
+	for _, expansion := range expansions {
+		name := bleve.NewTermQuery(strings.ToLower(expansion.Term))
+		name.SetField("Name")
+		name.SetBoost(3)
+		expanded.AddQuery(name)
+	}
+
+	return expanded
+}
+
+// expansionFor returns the expansion naming page, if any
+func expansionFor(page string, expansions []Expansion) (Expansion, bool) {
+	for _, expansion := range expansions {
+		if strings.EqualFold(expansion.Term, page) {
+			return expansion, true
+		}
+	}
+	return Expansion{}, false
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var tabStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("245")).
+	Background(lipgloss.Color("236")).
+	Padding(0, 1)
+
+// tabState is everything about a tab in the detail view. The active tab
+// lives in the Model's fields; the others are kept here until switched to.
+type tabState struct {
+	page          ManPage
+	key           renderKey
+	content       string       // "" until a tab restored from the last session is loaded
+	lines         []StyledLine // the page with its formatting
+	position      Bookmark     // line at the top of the view
+	searchQuery   string
+	searchPattern *regexp.Regexp
+	searchMatches []findMatch
+	currentMatch  int
+	expandedNotes map[int]bool
+	back          []Bookmark // pages read before this one, latest last
+	forward       []Bookmark // pages gone back from, latest last
+}
+
+// openInNewTab loads page into a new tab
+func (m Model) openInNewTab(page ManPage) tea.Cmd {
+	return m.loadIntoTab(page, true)
+}
+
+// loadIntoTab loads page like openPage, either into a new tab or in place of
+// the active tab's page without adding it to the tab's history
+func (m Model) loadIntoTab(page ManPage, newTab bool) tea.Cmd {
+	load := m.openPage(page)
+	return func() tea.Msg {
+		msg := load()
+		if loaded, ok := msg.(manContentLoadedMsg); ok {
+			loaded.newTab = newTab
+			loaded.navigating = !newTab
+			return loaded
+		}
+		return msg
+	}
+}
+
+// addTab opens an empty tab after the others and makes it active
+func (m *Model) addTab() {
+	if len(m.tabs) > 0 {
+		m.saveTab()
+	}
+	m.tabs = append(m.tabs, tabState{})
+	m.activeTab = len(m.tabs) - 1
+	m.pageBack = nil
+	m.pageForward = nil
+}
+
+// saveTab copies the active tab's state out of the Model
+func (m *Model) saveTab() {
+	if m.activeTab >= len(m.tabs) {
+		return
+	}
+	position := m.tabs[m.activeTab].position
+	if m.currentContent != "" {
+		position = m.currentPosition()
+	}
+	m.tabs[m.activeTab] = tabState{
+		page:          m.currentPage,
+		key:           m.detailKey,
+		content:       m.currentContent,
+		lines:         m.currentLines,
+		position:      position,
+		searchQuery:   m.searchQuery,
+		searchPattern: m.searchPattern,
+		searchMatches: m.searchMatches,
+		currentMatch:  m.currentMatch,
+		expandedNotes: m.expandedNotes,
+		back:          m.pageBack,
+		forward:       m.pageForward,
+	}
+}
+
+// loadTab makes tab i active, loading its page if it was restored from the
+// last session, or re-rendering it if the view has changed width since
+func (m *Model) loadTab(i int) tea.Cmd {
+	tab := m.tabs[i]
+	m.activeTab = i
+	m.mode = detailView
+	m.currentPage = tab.page
+	m.detailKey = tab.key
+	m.currentContent = tab.content
+	m.currentLines = tab.lines
+	m.searchQuery = tab.searchQuery
+	m.searchPattern = tab.searchPattern
+	m.searchMatches = tab.searchMatches
+	m.currentMatch = tab.currentMatch
+	m.expandedNotes = tab.expandedNotes
+	m.pageBack = tab.back
+	m.pageForward = tab.forward
+	m.pendingBookmark = nil
+
+	if tab.content == "" {
+		m.pageNotes = nil
+		m.layoutDetail()
+		position := tab.position
+		m.pendingBookmark = &position
+		return m.loadIntoTab(tab.page, false)
+	}
+
+	m.visitStart = time.Now()
+	m.placeNotes()
+	m.layoutDetail()
+	m.scrollToLine(bookmarkLine(strings.Split(m.currentContent, "\n"), tab.position))
+	return m.reflowDetail()
+}
+
+// switchTab moves to tab i, keeping the current tab as it is
+func (m *Model) switchTab(i int) tea.Cmd {
+	if i < 0 || i >= len(m.tabs) || i == m.activeTab {
+		return nil
+	}
+	m.endVisit()
+	m.saveTab()
+	return m.loadTab(i)
+}
+
+// closeTab closes the active tab, showing the next one, or the list once
+// the last is closed
+func (m *Model) closeTab() tea.Cmd {
+	m.endVisit()
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	if len(m.tabs) > 0 {
+		return m.loadTab(min(m.activeTab, len(m.tabs)-1))
+	}
+
+	m.activeTab = 0
+	m.mode = listView
+	m.currentPage = ManPage{}
+	m.currentContent = ""
+	m.currentLines = nil
+	m.searchQuery = ""
+	m.searchPattern = nil
+	m.searchMatches = nil
+	m.pageBack = nil
+	m.pageForward = nil
+	m.searchInput.SetValue("")
+	return m.filterPages("")
+}
+
+// navigate moves back through the active tab's history, or forward when
+// step is positive
+func (m *Model) navigate(step int) tea.Cmd {
+	from, to := &m.pageBack, &m.pageForward
+	if step > 0 {
+		from, to = to, from
+	}
+	if len(*from) == 0 {
+		if step > 0 {
+			m.status = "No later page in this tab"
+		} else {
+			m.status = "No earlier page in this tab"
+		}
+		return nil
+	}
+
+	target := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, m.currentPosition())
+	m.pendingBookmark = &target
+	return m.loadIntoTab(target.Page(), false)
+}
+
+// returnToTabs shows the open tabs again from the list, or restores the
+// tabs left open last time if none are open
+func (m *Model) returnToTabs() tea.Cmd {
+	if len(m.tabs) > 0 {
+		m.mode = detailView
+		m.visitStart = time.Now()
+		return m.reflowDetail()
+	}
+	if len(m.session.Tabs) == 0 {
+		m.status = "No tabs open"
+		return nil
+	}
+
+	for _, saved := range m.session.Tabs {
+		m.tabs = append(m.tabs, tabState{
+			page:     saved.Position.Page(),
+			position: saved.Position,
+			back:     saved.Back,
+			forward:  saved.Forward,
+		})
+	}
+	active := m.session.Active
+	// Restored; the session is saved again on quitting
+	m.session.Tabs = nil
+	return m.loadTab(active)
+}
+
+// saveSession saves the open tabs for the next launch. A session that was
+// never restored is kept unless other tabs were opened instead.
+func (m *Model) saveSession() error {
+	if len(m.tabs) == 0 && len(m.session.Tabs) > 0 {
+		return nil
+	}
+	m.saveTab()
+	tabs := make([]sessionTab, 0, len(m.tabs))
+	for _, tab := range m.tabs {
+		tabs = append(tabs, sessionTab{Position: tab.position, Back: tab.back, Forward: tab.forward})
+	}
+	return m.session.Save(m.activeTab, tabs)
+}
+
+// renderTabBar renders the open tabs in at most width columns, dropping
+// those furthest from the active one if they don't all fit
+func (m Model) renderTabBar(width int) string {
+	labels := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		page := tab.page
+		if i == m.activeTab {
+			page = m.currentPage
+		}
+		label := fmt.Sprintf("%d %s(%s)", i+1, page.Name, page.Section)
+		if i == m.activeTab {
+			labels[i] = titleStyle.Render(label)
+		} else {
+			labels[i] = tabStyle.Render(label)
+		}
+	}
+
+	first, last := 0, len(labels)-1
+	for first < last && lipgloss.Width(strings.Join(labels[first:last+1], " "))+4 > width {
+		if m.activeTab-first > last-m.activeTab {
+			first++
+		} else {
+			last--
+		}
+	}
+
+	bar := strings.Join(labels[first:last+1], " ")
+	if first > 0 {
+		bar = statusStyle.Render("… ") + bar
+	}
+	if last < len(labels)-1 {
+		bar += statusStyle.Render(" …")
+	}
+	return bar
+}
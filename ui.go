@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -18,6 +24,29 @@ const (
 	detailView
 	searchView
 	detailSearchView
+	relatedView
+	bookmarksView
+	historyView
+	noteInputView
+	compareView
+	diffView
+	filterInputView
+	filterView
+	visualView
+	examplesView
+)
+
+const (
+	// searchDebounce is how long typing must pause before a deep search runs
+	searchDebounce = 250 * time.Millisecond
+	// previewDelay is how long the cursor must rest on an uncached page
+	// before its preview is rendered
+	previewDelay = 80 * time.Millisecond
+	// resizeDebounce is how long the terminal size must settle before pages
+	// are re-rendered for it
+	resizeDebounce = 150 * time.Millisecond
+	// minRenderWidth keeps pages legible in very narrow panes
+	minRenderWidth = 30
 )
 
 // SectionFilter represents manual section filters
@@ -29,28 +58,105 @@ type SectionFilter struct {
 
 // Model represents the application state
 type Model struct {
-	mode                viewMode
-	manPages            []ManPage
-	filteredPages       []ManPage
-	cursor              int
-	viewport            viewport.Model
-	previewPort         viewport.Model
-	searchInput         textinput.Model
-	detailSearchInput   textinput.Model
-	currentContent      string
-	previewContent      string
-	searchQuery         string
-	searchMatches       []int // line numbers with matches
-	currentMatch        int   // index in searchMatches
-	sectionFilters      []SectionFilter
-	initialQuery        string
-	noMatchSuggestions  []ManPage
-	searchResultMatches map[string][]string // map of "name(section)" -> matches for search results
-	width               int
-	height              int
-	err                 error
-	loading             bool
-	loadingPreview      bool
+	mode               viewMode
+	manPages           []ManPage
+	filteredPages      []ManPage
+	cursor             int
+	viewport           viewport.Model
+	previewPort        viewport.Model
+	searchInput        textinput.Model
+	detailSearchInput  textinput.Model
+	currentPage        ManPage
+	currentContent     string       // plain text of the open page, for searching
+	currentLines       []StyledLine // the open page with its formatting
+	previewContent     string
+	previewKey         renderKey // page the preview pane should show
+	detailKey          renderKey // page and width shown in the detail view
+	resizeGen          int       // latest terminal resize; earlier ones don't re-render
+	config             Config
+	searchQuery        string
+	searchPattern      *regexp.Regexp // what's highlighted in the detail view
+	searchMatches      []findMatch
+	currentMatch       int       // index in searchMatches
+	find               findInput // the detail view's search input
+	filter             lineFilter
+	filterInput        textinput.Model
+	visual             visualSelection
+	copyPending        bool // y was pressed and waits for what to copy
+	showKeys           bool // the detail view's keys are listed instead of the page
+	sectionFilters     []SectionFilter
+	initialQuery       string
+	noMatchSuggestions []ManPage
+	speller            *Speller                // spelling suggestions, built in the background
+	correctedQuery     string                  // deep search query with misspelled words fixed
+	searchPages        []ManPage               // results of the active man -k or index search
+	searchGen          int                     // generation of the latest search; older results are dropped
+	cancelSearch       context.CancelFunc      // cancels the search in flight
+	searching          bool                    // a search is in flight
+	fuzzyMatches       map[string]FuzzyResult  // map of "name(section)" -> list filter match
+	searchResults      map[string]SearchResult // map of "name(section)" -> deep search result
+	deepSearch         bool                    // searching the full-text index instead of man -k
+	facets             *SearchFacets           // hit counts of the last deep search
+	deepTotal          uint64                  // deep search hits across all pages
+	loadingMore        bool                    // fetching the next page of deep search hits
+	related            []RelatedPage           // pages related to currentPage
+	relatedFor         string                  // "name(section)" the related pages belong to
+	relatedCursor      int
+	relatedErr         error
+	loadingRelated     bool
+	bookmarks          *Bookmarks
+	onlyBookmarked     bool   // list shows bookmarked pages only
+	bookmarkTag        string // collection shown in the bookmarks view; "" for all
+	bookmarkCursor     int
+	bookmarkReturn     viewMode  // view the bookmarks view was opened from
+	pendingBookmark    *Bookmark // position to scroll to once its page loads
+	editingTags        bool
+	tagInput           textinput.Model
+	status             string    // feedback for the last action, cleared on the next key
+	history            *History  // nil when history is disabled
+	visitStart         time.Time // when the page in the detail view was opened
+	recentCount        int       // leading entries of filteredPages that are the recent section
+	historyCursor      int
+	historyByFrecency  bool
+	historyReturn      viewMode
+	confirmClear       bool // the next D clears the whole history
+	notes              *NoteStore
+	pageNotes          []placedNote // notes on the open page, by line
+	expandedNotes      map[int]bool // indexes into pageNotes shown in full
+	pendingNote        Note         // anchor of the note being written
+	noteInput          textinput.Model
+	displayRows        []string // the detail view's rendered rows
+	displayMap         []int    // page line of each row; -1 for expanded notes
+	contentRow         []int    // row of each page line
+	tabs               []tabState
+	activeTab          int
+	pageBack           []Bookmark // the active tab's history, latest last
+	pageForward        []Bookmark
+	session            *Session // tabs left open last time
+	compare            comparison
+	snapshots          *SnapshotStore
+	changes            pageChanges     // the diff view's page and its changes
+	onlyChanged        bool            // list shows pages changed since their last snapshot only
+	changedPages       map[string]bool // "name(section)" of those pages; nil until checked
+	examples           []Example       // the open page's examples
+	exampleCursor      int
+	exampleOffset      int          // first example on screen
+	pickExample        bool         // opened with --pick-example to choose one of startPage's examples
+	widget             *shellWidget // command line lazyman was run on by a shell widget; nil otherwise
+	startPage          ManPage      // page opened at start by --pick-example or a shell widget
+	picked             string       // text to print once lazyman quits
+	pickedPoint        int          // cursor position in the command line a shell widget gets back
+	quitting           bool
+	splitRatio         float64   // share of the width the list takes beside the preview
+	dragging           bool      // the divider between the list and preview is being dragged
+	lastClick          time.Time // when a page in the list was last clicked, to spot double clicks
+	lastClickRow       int       // index of that page
+	width              int
+	height             int
+	err                error
+	loadWarning        error // files that couldn't be loaded, and so won't be saved; shown until dismissed
+	loading            bool
+	loadingPreview     bool
 }
 
 // Styles
@@ -79,6 +185,16 @@ var (
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")).
 			Bold(true)
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("208"))
+
+	fuzzyMatchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")).
+			Bold(true)
+
+	bookmarkMarkStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("220"))
 )
 
 // InitialModel creates the initial model
@@ -94,6 +210,23 @@ func InitialModel(initialQuery string) Model {
 	dsi.CharLimit = 156
 	dsi.Width = 50
 
+	tagi := textinput.New()
+	tagi.Placeholder = "networking, onboarding"
+	tagi.CharLimit = 156
+	tagi.Width = 50
+
+	ni := textinput.New()
+	ni.Prompt = "✎ "
+	ni.Placeholder = "Write a note on this line..."
+	ni.CharLimit = 500
+	ni.Width = 70
+
+	fi := textinput.New()
+	fi.Prompt = "&"
+	fi.Placeholder = "show only lines matching..."
+	fi.CharLimit = 156
+	fi.Width = 50
+
 	vp := viewport.New(80, 20)
 	pp := viewport.New(40, 20)
 
@@ -110,8 +243,14 @@ func InitialModel(initialQuery string) Model {
 		{Section: "9", Name: "Kernel Developer's", Enabled: true},
 	}
 
+	bookmarks, err := LoadBookmarks()
+	history, historyErr := LoadHistory()
+	session, sessionErr := LoadSession()
+	snapshots, snapshotsErr := LoadSnapshots()
+
 	return Model{
 		mode:              listView,
+		splitRatio:        defaultSplitRatio,
 		manPages:          []ManPage{},
 		filteredPages:     []ManPage{},
 		cursor:            0,
@@ -119,23 +258,66 @@ func InitialModel(initialQuery string) Model {
 		previewPort:       pp,
 		searchInput:       ti,
 		detailSearchInput: dsi,
+		tagInput:          tagi,
+		noteInput:         ni,
+		filterInput:       fi,
+		notes:             &NoteStore{pages: make(map[string][]Note)},
+		bookmarks:         bookmarks,
+		history:           history,
+		session:           session,
+		snapshots:         snapshots,
+		loadWarning:       errors.Join(err, historyErr, sessionErr, snapshotsErr),
 		sectionFilters:    filters,
 		initialQuery:      initialQuery,
+		config:            defaultConfig(),
 		loading:           true,
 	}
 }
 
+// applyConfig applies the user's settings, loading the notes from the
+// configured directory
+func (m *Model) applyConfig(cfg Config) {
+	m.config = cfg
+	m.find.flags.smartCase = cfg.SmartCase
+	if !cfg.History {
+		m.history = nil
+	}
+
+	dir, err := notesDir(cfg)
+	if err != nil {
+		m.loadWarning = errors.Join(m.loadWarning, fmt.Errorf("no directory for notes: %w", err))
+		return
+	}
+	notes, err := LoadNotes(dir)
+	m.notes = notes
+	m.loadWarning = errors.Join(m.loadWarning, err)
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	if m.initialQuery != "" {
+	if m.widget != nil {
+		// Drawn below the prompt rather than on the alternate screen
+		return m.openPage(m.startPage)
+	}
+	if m.pickExample {
+		return tea.Batch(tea.EnterAltScreen, m.openPage(m.startPage))
+	}
+	// The full page list always loads; an initial search is shown over it
+	// until the search is cleared. It's started from Update, which can keep
+	// its cancel function.
+	if m.deepSearch || m.initialQuery != "" {
+		gen, query := m.searchGen, m.initialQuery
 		return tea.Batch(
 			tea.EnterAltScreen,
-			searchManPages(m.initialQuery),
+			func() tea.Msg { return searchDebounceMsg{gen: gen, query: query} },
+			loadManPages,
+			buildSpeller(m.deepSearch),
 		)
 	}
 	return tea.Batch(
 		tea.EnterAltScreen,
 		loadManPages,
+		buildSpeller(false),
 	)
 }
 
@@ -145,53 +327,147 @@ type manPagesLoadedMsg struct {
 }
 
 type manContentLoadedMsg struct {
+	key        renderKey
+	page       ManPage
+	content    string
+	newTab     bool // open in a new tab rather than the active one
+	navigating bool // moving through the tab's history; the page isn't added to it
+}
+
+// pageReflowedMsg carries the open page rendered at the detail view's new width
+type pageReflowedMsg struct {
+	key     renderKey
 	content string
 }
 
+// resizeSettledMsg fires once the terminal has stopped resizing; it is
+// ignored if another resize happened since
+type resizeSettledMsg struct {
+	gen int
+}
+
 type previewLoadedMsg struct {
+	key     renderKey
 	content string
 }
 
+// previewRequestMsg fires once the cursor has rested on a page whose preview
+// isn't cached; it is ignored if the cursor moved on
+type previewRequestMsg struct {
+	key renderKey
+}
+
+// searchResultsMsg carries man -k results for search generation gen
+type searchResultsMsg struct {
+	gen   int
+	pages []ManPage
+}
+
+type deepSearchResultsMsg struct {
+	gen     int
+	results []SearchResult
+	facets  *SearchFacets
+	total   uint64
+	from    int
+}
+
+// searchDebounceMsg fires once typing has paused; it is ignored if another
+// search started since
+type searchDebounceMsg struct {
+	gen   int
+	query string
+}
+
+// searchErrMsg reports a failed search; errors from superseded searches are dropped
+type searchErrMsg struct {
+	gen int
+	err error
+}
+
+type spellerReadyMsg struct {
+	speller *Speller
+}
+
 type errMsg struct {
 	err error
 }
 
 // Commands
 func loadManPages() tea.Msg {
-	pages, err := SearchManPages(".")
+	pages, err := SearchManPages(context.Background(), ".")
 	if err != nil {
 		return errMsg{err}
 	}
 	return manPagesLoadedMsg{pages: pages}
 }
 
-func loadManContent(name, section string) tea.Cmd {
+// buildSpeller indexes every page name for spelling suggestions, and for a
+// deep search the search index's terms too. Only a deep search opens the
+// index, so other sessions don't hold it.
+func buildSpeller(deepSearch bool) tea.Cmd {
+	return func() tea.Msg {
+		pages, err := GetManPages()
+		if err != nil {
+			return errMsg{err}
+		}
+		return spellerReadyMsg{speller: NewSpeller(pages, deepSearch)}
+	}
+}
+
+func loadManContent(key renderKey) tea.Cmd {
 	return func() tea.Msg {
-		content, err := GetManContent(name, section)
+		content, err := renderPage(key.name, key.section, key.width)
 		if err != nil {
 			return errMsg{err}
 		}
-		return manContentLoadedMsg{content: content}
+		return manContentLoadedMsg{key: key, page: ManPage{Name: key.name, Section: key.section}, content: content}
 	}
 }
 
-func searchManPages(query string) tea.Cmd {
+// reflowPage renders the open page again at a new width
+func reflowPage(key renderKey) tea.Cmd {
 	return func() tea.Msg {
-		pages, err := SearchManPages(query)
+		content, err := renderPage(key.name, key.section, key.width)
 		if err != nil {
 			return errMsg{err}
 		}
-		return manPagesLoadedMsg{pages: pages}
+		return pageReflowedMsg{key: key, content: content}
+	}
+}
+
+func searchManPages(ctx context.Context, gen int, query string) tea.Cmd {
+	return func() tea.Msg {
+		pages, err := SearchManPages(ctx, query)
+		if err != nil {
+			return searchErrMsg{gen, err}
+		}
+		return searchResultsMsg{gen: gen, pages: pages}
+	}
+}
+
+func searchIndex(ctx context.Context, gen int, query string, opts SearchOptions) tea.Cmd {
+	return func() tea.Msg {
+		response, err := SearchIndexedManPages(ctx, query, opts)
+		if err != nil {
+			return searchErrMsg{gen, err}
+		}
+		return deepSearchResultsMsg{
+			gen:     gen,
+			results: response.Results,
+			facets:  response.Facets,
+			total:   response.Total,
+			from:    opts.From,
+		}
 	}
 }
 
-func loadPreview(name, section string) tea.Cmd {
+func loadPreview(key renderKey) tea.Cmd {
 	return func() tea.Msg {
-		content, err := GetManContent(name, section)
+		content, err := renderPage(key.name, key.section, key.width)
 		if err != nil {
-			return previewLoadedMsg{content: fmt.Sprintf("Error loading preview: %v", err)}
+			return previewLoadedMsg{key: key, content: fmt.Sprintf("Error loading preview: %v", err)}
 		}
-		return previewLoadedMsg{content: content}
+		return previewLoadedMsg{key: key, content: content}
 	}
 }
 
@@ -202,122 +478,359 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		if m.widget != nil {
+			// Leave the shell's earlier output on screen
+			msg.Height = min(msg.Height, widgetHeight)
+		}
 		m.width = msg.Width
 		m.height = msg.Height
 		m.viewport.Width = msg.Width
 		m.viewport.Height = msg.Height - 5
-		// Split view: left 60%, right 40%
-		listWidth := int(float64(msg.Width) * 0.6)
-		previewWidth := msg.Width - listWidth - 2 // -2 for border
-		m.previewPort.Width = previewWidth
-		m.previewPort.Height = msg.Height - 5
+		m.resizePreview()
+
+		// Re-render at the new widths once resizing stops
+		m.resizeGen++
+		gen := m.resizeGen
+		cmds = append(cmds, tea.Tick(resizeDebounce, func(time.Time) tea.Msg {
+			return resizeSettledMsg{gen: gen}
+		}))
+
+	case resizeSettledMsg:
+		if msg.gen == m.resizeGen {
+			cmds = append(cmds, m.reflowPreview(), m.reflowDetail())
+			if m.mode == compareView {
+				cmds = append(cmds, m.reflowCompare())
+			}
+		}
+
+	case pageReflowedMsg:
+		if msg.key == m.detailKey {
+			m.setDetailContent(msg.content)
+		}
 
 	case manPagesLoadedMsg:
 		m.manPages = msg.pages
+		if m.initialQuery != "" {
+			// The list belongs to a search; the full list is kept for when
+			// the search is cleared
+			break
+		}
+		m.loading = false
+		if m.searchInput.Value() != "" {
+			// Keep the filter typed while the list was loading
+			cmds = append(cmds, m.filterPages(m.searchInput.Value()))
+			break
+		}
+		m.showAllPages()
+		m.cursor = 0
+
+		// Load preview for first item
+		if len(m.filteredPages) > 0 {
+			page := m.filteredPages[0]
+			cmds = append(cmds, m.previewPage(page))
+		}
+
+	case searchResultsMsg:
+		if msg.gen != m.searchGen {
+			break
+		}
+		m.searching = false
+		m.searchPages = msg.pages
 		m.filteredPages = m.applyFilters(msg.pages)
+		m.recentCount = 0
+		m.fuzzyMatches = nil
+		m.noMatchSuggestions = nil
 		m.loading = false
+		m.err = nil
 		m.cursor = 0
 
-		// Handle initial query behavior
-		if m.initialQuery != "" {
-			if len(m.filteredPages) == 0 {
-				// No matches - find fuzzy suggestions
-				m.noMatchSuggestions = m.findFuzzySuggestions(m.initialQuery, m.manPages)
-				// Load preview for first suggestion
-				if len(m.noMatchSuggestions) > 0 {
-					page := m.noMatchSuggestions[0]
-					cmds = append(cmds, loadPreview(page.Name, page.Section))
-				}
-			} else if len(m.filteredPages) == 1 {
-				// Single match - auto-open
-				page := m.filteredPages[0]
-				m.initialQuery = "" // Clear so we don't re-trigger
-				return m, loadManContent(page.Name, page.Section)
+		if len(m.filteredPages) == 0 {
+			// No matches - suggest similarly spelled pages
+			m.suggestCorrections(m.initialQuery)
+			// Load preview for first suggestion
+			if len(m.noMatchSuggestions) > 0 {
+				page := m.noMatchSuggestions[0]
+				cmds = append(cmds, m.previewPage(page))
 			}
-			// Multiple matches - show list (normal behavior)
-			m.initialQuery = "" // Clear so we don't re-trigger
+			break
+		}
+		if len(m.filteredPages) == 1 && m.mode == listView {
+			// Single match - auto-open
+			page := m.filteredPages[0]
+			return m, m.openPage(page)
 		}
 
 		// Load preview for first item
-		if len(m.filteredPages) > 0 {
+		page := m.filteredPages[0]
+		cmds = append(cmds, m.previewPage(page))
+
+	case searchDebounceMsg:
+		if msg.gen == m.searchGen {
+			cmds = append(cmds, m.startSearch(msg.query))
+		}
+
+	case searchErrMsg:
+		if msg.gen != m.searchGen || errors.Is(msg.err, context.Canceled) {
+			break
+		}
+		m.searching = false
+		m.err = msg.err
+		m.loading = false
+		m.loadingMore = false
+
+	case deepSearchResultsMsg:
+		if msg.gen != m.searchGen {
+			// Superseded by a newer search
+			break
+		}
+		m.searching = false
+		if msg.from > 0 {
+			// Next page of the current results; drop it if the list has
+			// been replaced in the meantime
+			if m.loadingMore && msg.from == len(m.searchPages) {
+				for _, result := range msg.results {
+					m.searchPages = append(m.searchPages, result.ManPage)
+					key := fmt.Sprintf("%s(%s)", result.ManPage.Name, result.ManPage.Section)
+					m.searchResults[key] = result
+				}
+				m.filteredPages = m.toggleFilter(m.searchPages)
+				m.recentCount = 0
+			}
+			m.loadingMore = false
+			break
+		}
+
+		pages := make([]ManPage, 0, len(msg.results))
+		m.searchResults = make(map[string]SearchResult)
+		for _, result := range msg.results {
+			pages = append(pages, result.ManPage)
+			key := fmt.Sprintf("%s(%s)", result.ManPage.Name, result.ManPage.Section)
+			m.searchResults[key] = result
+		}
+		m.searchPages = pages
+		m.filteredPages = m.toggleFilter(pages)
+		m.recentCount = 0
+		m.fuzzyMatches = nil
+		m.facets = msg.facets
+		m.deepTotal = msg.total
+		m.loadingMore = false
+		m.loading = false
+		m.err = nil
+		m.cursor = 0
+		m.noMatchSuggestions = nil
+		m.correctedQuery = ""
+
+		if len(pages) == 0 {
+			m.suggestCorrections(m.initialQuery)
+		} else if len(m.filteredPages) > 0 {
 			page := m.filteredPages[0]
-			// Check if we have search matches for this page
 			key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
-			if matches, exists := m.searchResultMatches[key]; exists && len(matches) > 0 {
-				// Show matches immediately without loading man page
-				m.showSearchMatches(matches)
+			if result := m.searchResults[key]; len(result.Snippets) > 0 {
+				m.showSearchMatches(result)
 			} else {
-				cmds = append(cmds, loadPreview(page.Name, page.Section))
+				cmds = append(cmds, m.previewPage(page))
 			}
 		}
 
 	case manContentLoadedMsg:
-		m.currentContent = msg.content
-		m.viewport.SetContent(msg.content)
+		if msg.navigating && (m.pendingBookmark == nil || m.pendingBookmark.Page() != msg.page) {
+			// The tab it was meant for has been left
+			break
+		}
+		m.endVisit()
+		switch {
+		case msg.newTab || len(m.tabs) == 0:
+			m.addTab()
+		case !msg.navigating && m.currentContent != "":
+			m.pageBack = append(m.pageBack, m.currentPosition())
+			m.pageForward = nil
+		}
+		m.visitStart = time.Now()
+		if err := m.history.Visit(msg.page, m.visitStart); err != nil {
+			m.status = err.Error()
+		}
+		m.detailKey = msg.key
+		m.currentPage = msg.page
+		m.currentLines = ParseManFormatting(msg.content)
+		m.currentContent = plainText(m.currentLines)
+		m.expandedNotes = nil
+		m.placeNotes()
+		m.layoutDetail()
 		m.mode = detailView
 		m.viewport.GotoTop()
-
-		// If there's an initial query from index search, auto-highlight it
-		if m.initialQuery != "" && m.searchQuery == "" {
-			m.searchQuery = m.initialQuery
-			m.searchMatches = m.findMatches(m.initialQuery)
-			if len(m.searchMatches) > 0 {
+		m.searchQuery = ""
+		m.searchPattern = nil
+		m.searchMatches = nil
+		m.currentMatch = 0
+
+		// Coming from index search, highlight the words that matched
+		if m.deepSearch {
+			result := m.searchResults[fmt.Sprintf("%s(%s)", msg.page.Name, msg.page.Section)]
+			if pattern := termsPattern(result.Terms); pattern != nil {
+				m.searchQuery = m.initialQuery
+				m.searchPattern = pattern
+				m.searchMatches = m.findMatches(pattern)
 				m.currentMatch = 0
-				m.viewport.SetYOffset(m.searchMatches[0])
+				if len(m.searchMatches) > 0 {
+					m.scrollToLine(m.searchMatches[0].line)
+				}
+			}
+		}
+
+		// Opened from a bookmark on a heading or line
+		if b := m.pendingBookmark; b != nil && b.Name == msg.page.Name && b.Section == msg.page.Section {
+			m.scrollToLine(bookmarkLine(strings.Split(m.currentContent, "\n"), *b))
+		}
+		m.pendingBookmark = nil
+
+		if m.pickExample {
+			m.openExamples()
+			if len(m.examples) == 0 {
+				m.quitting = true
+				return m, tea.Quit
+			}
+		}
+		if m.widget != nil && m.widget.flag != "" {
+			if line := optionEntryFor(strings.Split(m.currentContent, "\n"), m.widget.flag); line >= 0 {
+				m.scrollToLine(line)
+			} else {
+				m.status = fmt.Sprintf("No entry for %s", m.widget.flag)
 			}
 		}
 
+	case notesEditedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Editor failed: %v", msg.err)
+		}
+		if err := m.notes.Reload(msg.page.Name, msg.page.Section); err != nil {
+			m.status = err.Error()
+		}
+		if msg.page == m.currentPage {
+			m.placeNotes()
+			m.expandedNotes = nil
+			m.relayoutDetail()
+		}
+
+	case compareLoadedMsg:
+		if m.mode != compareView || msg.key != m.compare.panes[msg.side].key {
+			break
+		}
+		if msg.err != nil {
+			m.compare.panes[msg.side].err = msg.err
+			break
+		}
+		m.setCompareContent(msg.side, msg.content)
+
+	case clipboardMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Couldn't copy: %v", msg.err)
+		} else {
+			m.status = "Copied " + msg.what
+		}
+
+	case diffLoadedMsg:
+		if m.mode != diffView || msg.page != m.changes.page {
+			break
+		}
+		m.changes.loading = false
+		m.changes.lines = msg.lines
+		m.changes.err = msg.err
+		m.scrollDiff(0)
+
+	case changedPagesMsg:
+		m.changedPages = msg.pages
+		if m.onlyChanged {
+			cmds = append(cmds, m.refilter())
+		}
+
+	case relatedLoadedMsg:
+		if msg.key == m.relatedFor {
+			m.related = msg.pages
+			m.relatedErr = msg.err
+			m.relatedCursor = 0
+			m.loadingRelated = false
+		}
+
+	case previewRequestMsg:
+		if msg.key == m.previewKey {
+			cmds = append(cmds, loadPreview(msg.key))
+		}
+
 	case previewLoadedMsg:
-		m.previewContent = msg.content
-		m.previewPort.SetContent(msg.content)
-		m.previewPort.GotoTop()
-		m.loadingPreview = false
+		// Drop previews for pages the cursor has since left
+		if msg.key == m.previewKey {
+			m.setPreview(msg.content)
+		}
+
+	case spellerReadyMsg:
+		m.speller = msg.speller
+		// Results may have arrived before the speller was ready
+		if !m.loading && len(m.filteredPages) == 0 && len(m.noMatchSuggestions) == 0 {
+			query := m.initialQuery
+			if m.mode == searchView || m.searchInput.Value() != "" {
+				query = m.searchInput.Value()
+			}
+			m.suggestCorrections(query)
+		}
 
 	case errMsg:
 		m.err = msg.err
 		m.loading = false
+		if m.pickExample || m.widget != nil {
+			m.quitting = true
+			return m, tea.Quit
+		}
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
 
 	case tea.KeyMsg:
+		m.status = ""
 		switch m.mode {
 		case listView:
 			switch msg.String() {
 			case "ctrl+c":
 				return m, tea.Quit
 
+			case "esc":
+				m.loadWarning = nil
+
 			case "q":
 				// If there's an active search, clear it; otherwise quit
 				if m.searchInput.Value() != "" {
 					m.searchInput.SetValue("")
-					m.filteredPages = m.applyFilters(m.manPages)
-					m.cursor = 0
-					if len(m.filteredPages) > 0 {
-						page := m.filteredPages[0]
-						m.loadingPreview = true
-						cmds = append(cmds, loadPreview(page.Name, page.Section))
-					}
+					cmds = append(cmds, m.filterPages(""))
 				} else {
 					return m, tea.Quit
 				}
 
+			case "t":
+				// Open the page under the cursor in a new tab
+				pages := m.filteredPages
+				if len(pages) == 0 && len(m.noMatchSuggestions) > 0 {
+					pages = m.noMatchSuggestions
+				}
+				if m.cursor < len(pages) {
+					return m, m.openInNewTab(pages[m.cursor])
+				}
+
+			case "T":
+				return m, m.returnToTabs()
+
+			case "c":
+				// Compare the page under the cursor with the open page
+				pages := m.filteredPages
+				if len(pages) == 0 && len(m.noMatchSuggestions) > 0 {
+					pages = m.noMatchSuggestions
+				}
+				if m.cursor < len(pages) {
+					return m, m.compareWithTab(pages[m.cursor])
+				}
+
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
-					// Load preview for new cursor position
-					pages := m.filteredPages
-					if len(pages) == 0 && len(m.noMatchSuggestions) > 0 {
-						pages = m.noMatchSuggestions
-					}
-					if len(pages) > 0 {
-						page := pages[m.cursor]
-						// Check if we have search matches
-						key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
-						if matches, exists := m.searchResultMatches[key]; exists && len(matches) > 0 {
-							m.showSearchMatches(matches)
-						} else {
-							m.loadingPreview = true
-							cmds = append(cmds, loadPreview(page.Name, page.Section))
-						}
-					}
+					cmds = append(cmds, m.previewSelected())
 				}
 
 			case "down", "j":
@@ -327,22 +840,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				if m.cursor < maxLen-1 {
 					m.cursor++
-					// Load preview for new cursor position
-					pages := m.filteredPages
-					if len(pages) == 0 && len(m.noMatchSuggestions) > 0 {
-						pages = m.noMatchSuggestions
-					}
-					if len(pages) > 0 {
-						page := pages[m.cursor]
-						// Check if we have search matches
-						key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
-						if matches, exists := m.searchResultMatches[key]; exists && len(matches) > 0 {
-							m.showSearchMatches(matches)
-						} else {
-							m.loadingPreview = true
-							cmds = append(cmds, loadPreview(page.Name, page.Section))
-						}
-					}
+					cmds = append(cmds, m.loadMoreResults(), m.previewSelected())
 				}
 
 			case "enter":
@@ -352,7 +850,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				if len(pages) > 0 && m.cursor < len(pages) {
 					page := pages[m.cursor]
-					return m, loadManContent(page.Name, page.Section)
+					return m, m.openPage(page)
 				}
 
 			case "/":
@@ -360,72 +858,77 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.searchInput.SetValue("")
 				return m, textinput.Blink
 
+			case "tab":
+				// Re-run a deep search with the suggested spelling
+				if m.deepSearch && m.correctedQuery != "" {
+					m.searchInput.SetValue(m.correctedQuery)
+					return m, m.startSearch(m.correctedQuery)
+				}
+
 			case "r":
 				m.loading = true
 				return m, loadManPages
 
-			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
-				// Toggle filter for this section
-				section := msg.String()
-				for i := range m.sectionFilters {
-					if m.sectionFilters[i].Section == section {
-						m.sectionFilters[i].Enabled = !m.sectionFilters[i].Enabled
-						break
-					}
-				}
-				// Reapply filters
-				m.filteredPages = m.applyFilters(m.manPages)
-				if m.cursor >= len(m.filteredPages) {
-					m.cursor = len(m.filteredPages) - 1
+			case "m":
+				// Bookmark the page under the cursor, or remove its bookmark
+				pages := m.filteredPages
+				if len(pages) == 0 && len(m.noMatchSuggestions) > 0 {
+					pages = m.noMatchSuggestions
 				}
-				if m.cursor < 0 {
-					m.cursor = 0
+				if m.cursor < len(pages) {
+					m.toggleBookmark(pages[m.cursor])
 				}
-				// Load preview for current cursor position
-				if len(m.filteredPages) > 0 {
-					page := m.filteredPages[m.cursor]
-					m.loadingPreview = true
-					cmds = append(cmds, loadPreview(page.Name, page.Section))
+				if m.onlyBookmarked {
+					return m, m.refilter()
 				}
+
+			case "B":
+				m.openBookmarks()
+
+			case "H":
+				m.openHistory()
+
+			case "*":
+				m.onlyBookmarked = !m.onlyBookmarked
+				return m, m.refilter()
+
+			case "U":
+				return m, m.toggleOnlyChanged()
+
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				return m, m.toggleSection(msg.String())
 			}
 
 		case detailView:
+			if m.showKeys {
+				// Any key closes the list
+				m.showKeys = false
+				return m, nil
+			}
+			if m.copyPending {
+				m.copyPending = false
+				return m, m.quickCopy(msg.String())
+			}
+			if m.widget != nil {
+				if handled, cmd := m.updateWidgetKeys(msg); handled {
+					return m, cmd
+				}
+			}
+
 			switch msg.String() {
 			case "ctrl+c", "q":
-				m.mode = listView
-				m.currentContent = ""
-				m.searchQuery = ""
-				m.searchMatches = nil
-				// Clear the search input and restore full list
-				m.searchInput.SetValue("")
-				m.filteredPages = m.applyFilters(m.manPages)
-				m.cursor = 0
-				// Load preview for first item
-				if len(m.filteredPages) > 0 {
-					page := m.filteredPages[0]
-					m.loadingPreview = true
-					cmds = append(cmds, loadPreview(page.Name, page.Section))
-				}
+				// Back to the list; the tabs stay open
+				cmds = append(cmds, m.backToList())
 
 			case "esc":
 				// Clear search highlight if active, otherwise go back
 				if m.searchQuery != "" {
 					m.searchQuery = ""
+					m.searchPattern = nil
 					m.searchMatches = nil
 					m.currentMatch = 0
 				} else {
-					m.mode = listView
-					m.currentContent = ""
-					// Clear the search input and restore full list
-					m.searchInput.SetValue("")
-					m.filteredPages = m.applyFilters(m.manPages)
-					m.cursor = 0
-					// Load preview for first item
-					if len(m.filteredPages) > 0 {
-						page := m.filteredPages[0]
-						m.loadingPreview = true
-						cmds = append(cmds, loadPreview(page.Name, page.Section))
-					}
+					cmds = append(cmds, m.backToList())
 				}
 
 			case "up", "k":
@@ -446,28 +949,94 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "d":
 				m.viewport.HalfViewDown()
 
+			case "R":
+				return m, m.openRelated()
+
+			case "D":
+				return m, m.openDiff()
+
+			case "tab":
+				if len(m.tabs) > 1 {
+					return m, m.switchTab((m.activeTab + 1) % len(m.tabs))
+				}
+
+			case "shift+tab":
+				if len(m.tabs) > 1 {
+					return m, m.switchTab((m.activeTab + len(m.tabs) - 1) % len(m.tabs))
+				}
+
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				return m, m.switchTab(int(msg.String()[0] - '1'))
+
+			case "x":
+				return m, m.closeTab()
+
+			case "c":
+				return m, m.compareWithTab(m.currentPage)
+
+			case "<":
+				return m, m.navigate(-1)
+
+			case ">":
+				return m, m.navigate(1)
+
+			case "m":
+				m.bookmarkPosition()
+
+			case "B":
+				m.openBookmarks()
+				return m, nil
+
+			case "a":
+				return m, m.startNote()
+
+			case "e":
+				return m, m.editNotes()
+
+			case "o":
+				m.toggleNoteOnScreen()
+
+			case "O":
+				m.toggleAllNotes()
+
+			case "]":
+				m.jumpToNote(1)
+
+			case "[":
+				m.jumpToNote(-1)
+
 			case "/":
-				m.mode = detailSearchView
-				m.detailSearchInput.SetValue("")
-				m.detailSearchInput.Focus()
-				return m, textinput.Blink
+				return m, m.startFind()
+
+			case "&":
+				return m, m.startFilter()
+
+			case "v":
+				m.startVisual(false)
+				return m, nil
+
+			case "V":
+				m.startVisual(true)
+				return m, nil
+
+			case "E":
+				m.openExamples()
+				return m, nil
+
+			case "y":
+				m.copyPending = true
+				m.status = "Copy: s synopsis • o option • e example • y line"
+				return m, nil
 
 			case "n":
-				// Next match
-				if len(m.searchMatches) > 0 {
-					m.currentMatch = (m.currentMatch + 1) % len(m.searchMatches)
-					m.viewport.SetYOffset(m.searchMatches[m.currentMatch])
-				}
+				m.jumpToMatch(1)
 
 			case "N":
-				// Previous match
-				if len(m.searchMatches) > 0 {
-					m.currentMatch--
-					if m.currentMatch < 0 {
-						m.currentMatch = len(m.searchMatches) - 1
-					}
-					m.viewport.SetYOffset(m.searchMatches[m.currentMatch])
-				}
+				m.jumpToMatch(-1)
+
+			case "?":
+				m.showKeys = true
+				return m, nil
 			}
 			m.viewport, cmd = m.viewport.Update(msg)
 			cmds = append(cmds, cmd)
@@ -477,72 +1046,76 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "esc":
 				m.mode = listView
 				// Restore original list
-				m.filteredPages = m.applyFilters(m.manPages)
-				m.cursor = 0
-				if len(m.filteredPages) > 0 {
-					page := m.filteredPages[0]
-					m.loadingPreview = true
-					cmds = append(cmds, loadPreview(page.Name, page.Section))
-				}
+				cmds = append(cmds, m.filterPages(""))
 
 			case "enter":
 				// Just close search mode, results are already updated
 				m.mode = listView
 
+				// Search right away rather than waiting out the debounce
+				if m.deepSearch && m.searchInput.Value() != "" {
+					return m, m.startSearch(m.searchInput.Value())
+				}
+
 			default:
 				m.searchInput, cmd = m.searchInput.Update(msg)
 				cmds = append(cmds, cmd)
 
-				// Trigger search on each keystroke
-				query := m.searchInput.Value()
-				if query != "" {
-					// Search and update filtered pages in real-time
-					cmds = append(cmds, searchManPages(query))
-				} else {
-					// If query is empty, show all pages
-					m.filteredPages = m.applyFilters(m.manPages)
-					m.cursor = 0
-					if len(m.filteredPages) > 0 {
-						page := m.filteredPages[0]
-						m.loadingPreview = true
-						cmds = append(cmds, loadPreview(page.Name, page.Section))
-					}
+				if m.deepSearch {
+					cmds = append(cmds, m.debounceSearch(m.searchInput.Value()))
+					break
 				}
+
+				// Filter the loaded pages on each keystroke
+				cmds = append(cmds, m.filterPages(m.searchInput.Value()))
 			}
 
+		case relatedView:
+			return m.updateRelatedView(msg)
+
+		case bookmarksView:
+			return m.updateBookmarksView(msg)
+
+		case historyView:
+			return m.updateHistoryView(msg)
+
+		case noteInputView:
+			return m.updateNoteInputView(msg)
+
+		case compareView:
+			return m.updateCompareView(msg)
+
+		case diffView:
+			return m.updateDiffView(msg)
+
 		case detailSearchView:
-			switch msg.String() {
-			case "esc":
-				m.mode = detailView
-				m.detailSearchInput.Blur()
+			return m.updateDetailSearchView(msg)
 
-			case "enter":
-				query := m.detailSearchInput.Value()
-				if query != "" {
-					m.searchQuery = query
-					m.searchMatches = m.findMatches(query)
-					m.currentMatch = 0
-					if len(m.searchMatches) > 0 {
-						m.viewport.SetYOffset(m.searchMatches[0])
-					}
-				}
-				m.mode = detailView
-				m.detailSearchInput.Blur()
+		case filterInputView:
+			return m.updateFilterInputView(msg)
 
-			default:
-				m.detailSearchInput, cmd = m.detailSearchInput.Update(msg)
-				cmds = append(cmds, cmd)
-			}
+		case filterView:
+			return m.updateFilterView(msg)
+
+		case visualView:
+			return m.updateVisualView(msg)
+
+		case examplesView:
+			return m.updateExamplesView(msg)
 		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
-// applyFilters filters manual pages based on enabled section filters
+// applyFilters filters manual pages based on enabled section filters and the
+// bookmarked-only and changed-only toggles
 func (m Model) applyFilters(pages []ManPage) []ManPage {
 	filtered := []ManPage{}
 	for _, page := range pages {
+		if !m.passesToggles(page) {
+			continue
+		}
 		// Check if this section is enabled
 		for _, filter := range m.sectionFilters {
 			if filter.Section == page.Section && filter.Enabled {
@@ -554,179 +1127,669 @@ func (m Model) applyFilters(pages []ManPage) []ManPage {
 	return filtered
 }
 
-// levenshteinDistance calculates edit distance between two strings
-func levenshteinDistance(s1, s2 string) int {
-	s1Lower := strings.ToLower(s1)
-	s2Lower := strings.ToLower(s2)
-
-	if len(s1Lower) == 0 {
-		return len(s2Lower)
+// toggleFilter keeps the pages passing the bookmarked-only and changed-only
+// toggles. Deep search results are filtered by section in the index, so only
+// these filters apply to them.
+func (m Model) toggleFilter(pages []ManPage) []ManPage {
+	if !m.onlyBookmarked && !m.onlyChanged {
+		return pages
 	}
-	if len(s2Lower) == 0 {
-		return len(s1Lower)
+	filtered := []ManPage{}
+	for _, page := range pages {
+		if m.passesToggles(page) {
+			filtered = append(filtered, page)
+		}
 	}
+	return filtered
+}
+
+// passesToggles reports whether page is kept by the bookmarked-only and
+// changed-only toggles
+func (m Model) passesToggles(page ManPage) bool {
+	if m.onlyBookmarked && !m.bookmarks.Has(page.Name, page.Section) {
+		return false
+	}
+	return !m.onlyChanged || m.changedPages[fmt.Sprintf("%s(%s)", page.Name, page.Section)]
+}
 
-	// Create matrix
-	matrix := make([][]int, len(s1Lower)+1)
-	for i := range matrix {
-		matrix[i] = make([]int, len(s2Lower)+1)
-		matrix[i][0] = i
+// refilter reapplies the section and toggle filters to the current list,
+// keeping the cursor where possible
+func (m *Model) refilter() tea.Cmd {
+	if m.fuzzyMatches != nil {
+		return m.filterPages(m.searchInput.Value())
 	}
-	for j := range matrix[0] {
-		matrix[0][j] = j
+	switch {
+	case m.deepSearch && m.initialQuery != "":
+		m.filteredPages = m.toggleFilter(m.searchPages)
+		m.recentCount = 0
+	case m.initialQuery != "":
+		m.filteredPages = m.applyFilters(m.searchPages)
+		m.recentCount = 0
+	default:
+		m.showAllPages()
+	}
+	if m.cursor >= len(m.filteredPages) {
+		m.cursor = len(m.filteredPages) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	// Load preview for current cursor position
+	if len(m.filteredPages) == 0 {
+		return nil
 	}
+	return m.previewPage(m.filteredPages[m.cursor])
+}
 
-	// Fill matrix
-	for i := 1; i <= len(s1Lower); i++ {
-		for j := 1; j <= len(s2Lower); j++ {
-			cost := 0
-			if s1Lower[i-1] != s2Lower[j-1] {
-				cost = 1
-			}
+// filterPages ranks the loaded pages against query in-process, restoring the
+// full list when query is empty, and loads the preview of the first result
+func (m *Model) filterPages(query string) tea.Cmd {
+	m.fuzzyMatches = nil
+	m.noMatchSuggestions = nil
+	m.cursor = 0
+
+	if strings.TrimSpace(query) == "" {
+		// Clearing the filter also leaves any man -k or index search
+		m.searchGen++
+		if m.cancelSearch != nil {
+			m.cancelSearch()
+			m.cancelSearch = nil
+		}
+		m.searching = false
+		m.initialQuery = ""
+		m.searchPages = nil
+		m.searchResults = nil
+		m.correctedQuery = ""
+		m.facets = nil
+		m.deepTotal = 0
+		m.showAllPages()
+	} else {
+		results := FuzzyFilter(m.manPages, query)
+		m.history.BoostFuzzy(results)
+		m.fuzzyMatches = make(map[string]FuzzyResult, len(results))
+		pages := make([]ManPage, 0, len(results))
+		for _, result := range results {
+			pages = append(pages, result.Page)
+			m.fuzzyMatches[fmt.Sprintf("%s(%s)", result.Page.Name, result.Page.Section)] = result
+		}
 
-			min := matrix[i-1][j] + 1 // deletion
-			if matrix[i][j-1]+1 < min {
-				min = matrix[i][j-1] + 1 // insertion
+		// Task phrases such as "compress a directory" add the commands for them
+		for _, expansion := range ExpandQuery(query) {
+			for _, page := range m.manPages {
+				key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+				if _, seen := m.fuzzyMatches[key]; seen || !strings.EqualFold(page.Name, expansion.Term) {
+					continue
+				}
+				page.Via = expansion.Phrase
+				pages = append(pages, page)
+				m.fuzzyMatches[key] = FuzzyResult{Page: page}
 			}
-			if matrix[i-1][j-1]+cost < min {
-				min = matrix[i-1][j-1] + cost // substitution
+		}
+
+		// So do pages whose notes mention the query
+		if noted := m.notes.Search(query); len(noted) > 0 {
+			for _, page := range m.manPages {
+				key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+				if _, seen := m.fuzzyMatches[key]; seen || !noted[key] {
+					continue
+				}
+				pages = append(pages, page)
+				m.fuzzyMatches[key] = FuzzyResult{Page: page}
 			}
+		}
+
+		m.filteredPages = m.applyFilters(pages)
+		m.recentCount = 0
+		if len(m.filteredPages) == 0 {
+			m.suggestCorrections(query)
+		}
+	}
+
+	if len(m.filteredPages) == 0 {
+		return nil
+	}
+	return m.previewPage(m.filteredPages[0])
+}
 
-			matrix[i][j] = min
+// showAllPages lists every loaded page, after a section of the most
+// frecently read ones
+func (m *Model) showAllPages() {
+	all := m.applyFilters(m.manPages)
+	var recent []ManPage
+	if entries := m.history.Entries(true); len(entries) > 0 {
+		byKey := make(map[string]ManPage, len(all))
+		for _, page := range all {
+			byKey[fmt.Sprintf("%s(%s)", page.Name, page.Section)] = page
 		}
+		for _, entry := range entries {
+			if page, ok := byKey[fmt.Sprintf("%s(%s)", entry.Name, entry.Section)]; ok {
+				recent = append(recent, page)
+				if len(recent) == recentPageCount {
+					break
+				}
+			}
+		}
+	}
+	m.recentCount = len(recent)
+	m.filteredPages = append(recent, all...)
+}
+
+// backToList leaves the detail view for the list. The results of a man -k
+// or index search are listed again, with their paging and facets; a filter
+// typed over the full list is cleared.
+func (m *Model) backToList() tea.Cmd {
+	m.endVisit()
+	m.saveTab()
+	m.mode = listView
+	if m.initialQuery == "" {
+		m.searchInput.SetValue("")
+		return m.filterPages("")
+	}
+	if m.fuzzyMatches != nil {
+		m.searchInput.SetValue("")
+		m.fuzzyMatches = nil
+	}
+	return m.refilter()
+}
+
+// endVisit records how long the page in the detail view was read for
+func (m *Model) endVisit() {
+	if m.visitStart.IsZero() {
+		return
 	}
+	if err := m.history.AddDwell(m.currentPage, m.visitStart, time.Since(m.visitStart)); err != nil {
+		m.status = err.Error()
+	}
+	m.visitStart = time.Time{}
+}
+
+// previewPage shows page in the preview pane, straight from the render cache
+// when possible, and prefetches the pages around the cursor. Uncached pages
+// are rendered only once the cursor rests on them for previewDelay.
+func (m *Model) previewPage(page ManPage) tea.Cmd {
+	key := pageKey(page, m.previewRenderWidth())
+	m.previewKey = key
+	m.prefetchNeighbors()
 
-	return matrix[len(s1Lower)][len(s2Lower)]
+	if content, ok := renderedPages.Get(key); ok {
+		m.setPreview(content)
+		return nil
+	}
+	m.loadingPreview = true
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewRequestMsg{key: key}
+	})
 }
 
-// findFuzzySuggestions finds similar manual pages using fuzzy matching
-func (m Model) findFuzzySuggestions(query string, allPages []ManPage) []ManPage {
-	type scoredPage struct {
-		page  ManPage
-		score int
+// renderWidth returns the width to render pages at for a pane of the given
+// width, capped by the configured maximum
+func (m Model) renderWidth(pane int) int {
+	width := pane
+	if m.config.MaxWidth > 0 && width > m.config.MaxWidth {
+		width = m.config.MaxWidth
+	}
+	if width < minRenderWidth {
+		width = minRenderWidth
 	}
+	return width
+}
 
-	var scored []scoredPage
-	queryLower := strings.ToLower(query)
+// listWidth is the width of the page list, left of the preview. The list
+// keeps at least minListWidth columns however narrow the terminal or far the
+// divider is dragged.
+func (m Model) listWidth() int {
+	if m.width == 0 {
+		return 80 // default
+	}
+	width := int(float64(m.width) * m.splitRatio)
+	return max(min(width, m.width-minPreviewWidth-2), minListWidth) // -2 for border
+}
+
+// truncateText cuts text to at most width characters, ending it with "..."
+// when it's cut, and returns it with the number of bytes of text kept
+func truncateText(text string, width int) (string, int) {
+	if utf8.RuneCountInString(text) <= width {
+		return text, len(text)
+	}
+	end := 0
+	for range max(width-3, 0) {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	return text[:end] + "...", end
+}
 
-	for _, page := range allPages {
-		pageLower := strings.ToLower(page.Name)
+// resizePreview fits the preview pane beside the list
+func (m *Model) resizePreview() {
+	m.previewPort.Width = max(m.width-m.listWidth()-2, 1) // -2 for border
+	m.previewPort.Height = m.height - 5
+}
 
-		// Calculate similarity score (lower is better)
-		distance := levenshteinDistance(query, page.Name)
+// previewSelected loads the preview for the page under the cursor, or shows
+// its search matches if it has some
+func (m *Model) previewSelected() tea.Cmd {
+	pages := m.filteredPages
+	if len(pages) == 0 && len(m.noMatchSuggestions) > 0 {
+		pages = m.noMatchSuggestions
+	}
+	if m.cursor >= len(pages) {
+		return nil
+	}
+	page := pages[m.cursor]
+	// Check if we have search matches
+	key := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+	if result, exists := m.searchResults[key]; exists && len(result.Snippets) > 0 {
+		m.showSearchMatches(result)
+		return nil
+	}
+	return m.previewPage(page)
+}
 
-		// Also check if query is a substring (boost score)
-		if strings.Contains(pageLower, queryLower) {
-			distance -= 5 // Bonus for substring match
+// toggleSection turns the list's filter for a section on or off
+func (m *Model) toggleSection(section string) tea.Cmd {
+	for i := range m.sectionFilters {
+		if m.sectionFilters[i].Section == section {
+			m.sectionFilters[i].Enabled = !m.sectionFilters[i].Enabled
+			break
 		}
+	}
+	// Deep search results are re-queried rather than filtered, so hits
+	// beyond the current page of results aren't lost
+	if m.deepSearch && m.initialQuery != "" {
+		return m.startSearch(m.initialQuery)
+	}
+	return m.refilter()
+}
+
+// previewRenderWidth is the width pages are rendered at for the preview pane
+func (m Model) previewRenderWidth() int {
+	return m.renderWidth(m.previewPort.Width - 1)
+}
+
+// detailRenderWidth is the width pages are rendered at for the detail view,
+// which is narrower while the related pages panel is open
+func (m Model) detailRenderWidth() int {
+	width := m.viewport.Width
+	if m.mode == relatedView {
+		width = m.width - relatedPanelWidth - 3
+	}
+	return m.renderWidth(width - 2)
+}
+
+// openPage loads page into the detail view
+func (m Model) openPage(page ManPage) tea.Cmd {
+	return loadManContent(pageKey(page, m.detailRenderWidth()))
+}
+
+// reflowPreview re-renders the preview if the pane width changed
+func (m *Model) reflowPreview() tea.Cmd {
+	if m.previewKey.name == "" || m.previewKey.width == m.previewRenderWidth() {
+		return nil
+	}
+	return m.previewPage(ManPage{Name: m.previewKey.name, Section: m.previewKey.section})
+}
 
-		// Only include reasonable matches
-		if distance <= 5 {
-			scored = append(scored, scoredPage{page: page, score: distance})
+// reflowDetail re-renders the open page if the detail view's width changed
+func (m *Model) reflowDetail() tea.Cmd {
+	if m.mode == listView || m.mode == searchView || m.detailKey.name == "" {
+		return nil
+	}
+	width := m.detailRenderWidth()
+	if m.detailKey.width == width {
+		return nil
+	}
+	m.detailKey.width = width
+	if content, ok := renderedPages.Get(m.detailKey); ok {
+		m.setDetailContent(content)
+		return nil
+	}
+	return reflowPage(m.detailKey)
+}
+
+// setDetailContent swaps in a re-rendered copy of the open page, keeping the
+// reading position and search matches
+func (m *Model) setDetailContent(content string) {
+	oldLines := strings.Count(m.currentContent, "\n") + 1
+	position := float64(m.topLine()) / float64(oldLines)
+
+	m.currentLines = ParseManFormatting(content)
+	m.currentContent = plainText(m.currentLines)
+	m.placeNotes()
+	m.layoutDetail()
+	if m.searchPattern != nil {
+		m.searchMatches = m.findMatches(m.searchPattern)
+		if m.currentMatch >= len(m.searchMatches) {
+			m.currentMatch = 0
 		}
 	}
+	if m.filter.active {
+		m.applyFilter()
+	}
+	if m.mode == examplesView {
+		m.examples = extractExamples(strings.Split(m.currentContent, "\n"))
+		m.moveExampleCursor(0)
+	}
+	if m.mode == visualView {
+		// The selection's lines and columns don't survive reflowing the text
+		m.mode = detailView
+		m.status = "Selection cleared: the page was re-rendered"
+	}
+	newLines := strings.Count(content, "\n") + 1
+	m.scrollToLine(int(position * float64(newLines)))
+}
 
-	// Sort by score (best matches first)
-	for i := 0; i < len(scored)-1; i++ {
-		for j := i + 1; j < len(scored); j++ {
-			if scored[j].score < scored[i].score {
-				scored[i], scored[j] = scored[j], scored[i]
+// setPreview replaces the preview pane's content
+func (m *Model) setPreview(content string) {
+	m.previewContent = content
+	m.previewPort.SetContent(renderStyledLines(ParseManFormatting(content)))
+	m.previewPort.GotoTop()
+	m.loadingPreview = false
+}
+
+// prefetchNeighbors renders the pages just above and below the cursor in
+// the background, nearest first
+func (m *Model) prefetchNeighbors() {
+	pages := m.filteredPages
+	if len(pages) == 0 {
+		pages = m.noMatchSuggestions
+	}
+
+	var keys []renderKey
+	for d := 1; d <= prefetchDistance; d++ {
+		for _, i := range []int{m.cursor + d, m.cursor - d} {
+			if i >= 0 && i < len(pages) {
+				keys = append(keys, pageKey(pages[i], m.previewRenderWidth()))
 			}
 		}
 	}
+	prefetcher.Request(keys)
+}
+
+// startSearch cancels any search in flight and runs query against the index
+// in deep search mode, or man -k otherwise. Results from earlier searches are
+// dropped when they arrive, since their generation no longer matches.
+func (m *Model) startSearch(query string) tea.Cmd {
+	m.searchGen++
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSearch = cancel
+	m.initialQuery = query
+	m.correctedQuery = ""
+	m.searching = true
+	m.err = nil
+
+	if m.deepSearch {
+		return searchIndex(ctx, m.searchGen, query, m.searchOptions())
+	}
+	return searchManPages(ctx, m.searchGen, query)
+}
+
+// debounceSearch starts a search for query once typing pauses for
+// searchDebounce, cancelling the search in flight straight away
+func (m *Model) debounceSearch(query string) tea.Cmd {
+	m.searchGen++
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+		m.cancelSearch = nil
+	}
+	m.searching = false
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	gen := m.searchGen
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return searchDebounceMsg{gen: gen, query: query}
+	})
+}
+
+// loadMoreResults fetches the next page of deep search hits once the cursor
+// gets close to the end of the ones already loaded
+func (m *Model) loadMoreResults() tea.Cmd {
+	loaded := len(m.searchPages)
+	if !m.deepSearch || m.loadingMore || uint64(loaded) >= m.deepTotal || m.cursor < len(m.filteredPages)-10 {
+		return nil
+	}
 
-	// Return top 10 suggestions
-	result := []ManPage{}
-	max := 10
-	if len(scored) < max {
-		max = len(scored)
+	m.loadingMore = true
+	opts := m.searchOptions()
+	opts.From = loaded
+	if m.cancelSearch != nil {
+		m.cancelSearch()
 	}
-	for i := 0; i < max; i++ {
-		result = append(result, scored[i].page)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSearch = cancel
+	// Same generation as the search being paged, so a newer search drops it
+	return searchIndex(ctx, m.searchGen, m.initialQuery, opts)
+}
+
+// searchOptions returns deep search options matching the section filters
+func (m Model) searchOptions() SearchOptions {
+	var opts SearchOptions
+	for _, filter := range m.sectionFilters {
+		if !filter.Enabled {
+			opts.ExcludeSections = append(opts.ExcludeSections, filter.Section)
+		}
 	}
+	opts.Boosts = m.history.Boosts()
+	return opts
+}
 
-	return result
+// sectionHitCount sums deep search hits for a filter section, including
+// subsections such as "3p" under "3"
+func (m Model) sectionHitCount(section string) int {
+	count := 0
+	for s, n := range m.facets.Sections {
+		if strings.HasPrefix(s, section) {
+			count += n
+		}
+	}
+	return count
 }
 
-// showSearchMatches displays search result matches in the preview pane
-func (m *Model) showSearchMatches(matches []string) {
+// levenshteinDistance calculates edit distance between two strings, counting
+// a swap of adjacent characters ("grpe" for "grep") as a single edit
+func levenshteinDistance(s1, s2 string) int {
+	s1Lower := strings.ToLower(s1)
+	s2Lower := strings.ToLower(s2)
+
+	if len(s1Lower) == 0 {
+		return len(s2Lower)
+	}
+	if len(s2Lower) == 0 {
+		return len(s1Lower)
+	}
+
+	// Only the last three rows of the matrix are needed
+	prevPrev := make([]int, len(s2Lower)+1)
+	prev := make([]int, len(s2Lower)+1)
+	curr := make([]int, len(s2Lower)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s1Lower); i++ {
+		curr[0] = i
+		for j := 1; j <= len(s2Lower); j++ {
+			cost := 0
+			if s1Lower[i-1] != s2Lower[j-1] {
+				cost = 1
+			}
+
+			min := prev[j] + 1 // deletion
+			if curr[j-1]+1 < min {
+				min = curr[j-1] + 1 // insertion
+			}
+			if prev[j-1]+cost < min {
+				min = prev[j-1] + cost // substitution
+			}
+			if i > 1 && j > 1 && s1Lower[i-1] == s2Lower[j-2] && s1Lower[i-2] == s2Lower[j-1] && prevPrev[j-2]+1 < min {
+				min = prevPrev[j-2] + 1 // transposition
+			}
+
+			curr[j] = min
+		}
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+
+	return prev[len(s2Lower)]
+}
+
+// suggestCorrections fills the "did you mean" suggestions for a query that
+// matched nothing
+func (m *Model) suggestCorrections(query string) {
+	if m.speller == nil || strings.TrimSpace(query) == "" {
+		return
+	}
+	m.noMatchSuggestions = m.speller.SuggestPages(query, 10)
+	if m.deepSearch {
+		m.correctedQuery = m.speller.CorrectQuery(query)
+	}
+}
+
+// showSearchMatches displays deep search snippets in the preview pane
+func (m *Model) showSearchMatches(result SearchResult) {
 	var content strings.Builder
 	highlightStyle := lipgloss.NewStyle().
 		Background(lipgloss.Color("226")).
 		Foreground(lipgloss.Color("0")).
 		Bold(true)
 
-	if len(matches) == 0 {
+	if len(result.Snippets) == 0 {
 		content.WriteString("No matches found in content.")
 	} else {
-		for i, match := range matches {
+		for i, snippet := range result.Snippets {
 			if i > 0 {
 				content.WriteString("\n\n")
 			}
-			content.WriteString(fmt.Sprintf("─── Match %d ───\n", i+1))
-			// Highlight query in match
-			highlighted := m.highlightText(match, m.initialQuery, highlightStyle)
-			content.WriteString(highlighted)
+			content.WriteString(fmt.Sprintf("─── Match %d of %d ───\n",
+				snippet.Index, result.TotalHits))
+			// Highlight every matched term in the snippet
+			content.WriteString(highlightTerms(snippet.Text, result.Terms, highlightStyle))
 		}
 	}
 
 	m.previewPort.SetContent(content.String())
 	m.previewPort.GotoTop()
 	m.loadingPreview = false
+	// Any man page preview still loading is no longer wanted
+	m.previewKey = renderKey{}
 }
 
-// highlightText highlights occurrences of query in text
-func (m Model) highlightText(text, query string, style lipgloss.Style) string {
-	queryLower := strings.ToLower(query)
-	textLower := strings.ToLower(text)
-
-	if !strings.Contains(textLower, queryLower) {
-		return text
+// termAt returns the length of the longest term matching textLower at i, or 0
+func termAt(textLower string, i int, terms []string) int {
+	longest := 0
+	for _, term := range terms {
+		if len(term) > longest && strings.HasPrefix(textLower[i:], term) {
+			longest = len(term)
+		}
 	}
+	return longest
+}
 
-	result := ""
-	remaining := text
-	remainingLower := textLower
+// rowMatchPositions maps a filter match onto a list row of the form
+// "name(section) - description", keeping positions before visible and
+// shifting them by offset
+func rowMatchPositions(page ManPage, match FuzzyResult, visible, offset int) []int {
+	descStart := len(page.Name) + len(page.Section) + 2
+	if page.Via != "" {
+		descStart += len(fmt.Sprintf(" (for %q)", page.Via))
+	}
+	descStart += len(" - ")
 
-	for {
-		idx := strings.Index(remainingLower, queryLower)
-		if idx == -1 {
-			result += remaining
-			break
+	var positions []int
+	for _, pos := range match.NamePos {
+		if pos < visible {
+			positions = append(positions, pos+offset)
 		}
+	}
+	for _, pos := range match.DescPos {
+		if pos+descStart < visible {
+			positions = append(positions, pos+descStart+offset)
+		}
+	}
+	return positions
+}
 
-		result += remaining[:idx]
-		matchEnd := idx + len(query)
-		result += style.Render(remaining[idx:matchEnd])
+// highlightPositions renders text in base style with the bytes at the given
+// sorted positions in match style
+func highlightPositions(text string, positions []int, base, match lipgloss.Style) string {
+	var result strings.Builder
+	last := 0
+	for i := 0; i < len(positions); {
+		start := positions[i]
+		end := start + 1
+		for i++; i < len(positions) && positions[i] == end; i++ {
+			end++
+		}
+		if start > last {
+			result.WriteString(base.Render(text[last:start]))
+		}
+		result.WriteString(match.Render(text[start:end]))
+		last = end
+	}
+	if last < len(text) {
+		result.WriteString(base.Render(text[last:]))
+	}
+	return result.String()
+}
 
-		remaining = remaining[matchEnd:]
-		remainingLower = remainingLower[matchEnd:]
+// highlightTerms highlights case-insensitive occurrences of any of terms in text
+func highlightTerms(text string, terms []string, style lipgloss.Style) string {
+	var result strings.Builder
+	last := 0
+	for _, r := range termRanges(text, terms) {
+		result.WriteString(text[last:r[0]])
+		result.WriteString(style.Render(text[r[0]:r[1]]))
+		last = r[1]
 	}
+	result.WriteString(text[last:])
 
-	return result
+	return result.String()
 }
 
-// findMatches searches for query in current content and returns line numbers
-func (m Model) findMatches(query string) []int {
-	if query == "" || m.currentContent == "" {
+// termRanges returns the byte ranges of case-insensitive occurrences of any
+// of terms in text, in order and without overlaps
+func termRanges(text string, terms []string) [][2]int {
+	textLower := strings.ToLower(text)
+	if len(textLower) != len(text) || len(terms) == 0 {
+		// Lower-casing changed byte offsets; leave the text alone
 		return nil
 	}
 
-	lines := strings.Split(m.currentContent, "\n")
-	matches := []int{}
-	searchLower := strings.ToLower(query)
+	lowerTerms := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term != "" {
+			lowerTerms = append(lowerTerms, strings.ToLower(term))
+		}
+	}
 
-	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), searchLower) {
-			matches = append(matches, i)
+	var ranges [][2]int
+	for i := 0; i < len(text); {
+		n := termAt(textLower, i, lowerTerms)
+		if n == 0 {
+			i++
+			continue
 		}
+		ranges = append(ranges, [2]int{i, i + n})
+		i += n
 	}
+	return ranges
+}
 
-	return matches
+// findMatches returns the matches of pattern in the open page
+func (m Model) findMatches(pattern *regexp.Regexp) []findMatch {
+	return findAll(m.currentContent, pattern)
 }
 
 // View renders the UI
 func (m Model) View() string {
+	if m.quitting {
+		// Nothing is left behind in the shell
+		return ""
+	}
 	if m.loading {
 		return "\n  Loading man pages...\n\n"
 	}
@@ -734,17 +1797,44 @@ func (m Model) View() string {
 	switch m.mode {
 	case listView:
 		return m.renderListView()
-	case detailView:
+	case detailView, visualView:
 		return m.renderDetailView()
 	case searchView:
 		return m.renderSearchView()
 	case detailSearchView:
 		return m.renderDetailSearchView()
+	case relatedView:
+		return m.renderRelatedView()
+	case bookmarksView:
+		return m.renderBookmarksView()
+	case historyView:
+		return m.renderHistoryView()
+	case noteInputView:
+		return m.renderNoteInputView()
+	case compareView:
+		return m.renderCompareView()
+	case diffView:
+		return m.renderDiffView()
+	case filterInputView:
+		return m.renderFilterInputView()
+	case filterView:
+		return m.renderFilterView()
+	case examplesView:
+		return m.renderExamplesView()
 	default:
 		return ""
 	}
 }
 
+// sectionLabel is how a section's filter is shown in the filter bar
+func (m Model) sectionLabel(filter SectionFilter) string {
+	label := fmt.Sprintf("[%s]%s", filter.Section, filter.Name)
+	if m.facets != nil {
+		label += fmt.Sprintf(" (%d)", m.sectionHitCount(filter.Section))
+	}
+	return label
+}
+
 func (m Model) renderFilterBar() string {
 	var b strings.Builder
 
@@ -763,8 +1853,7 @@ func (m Model) renderFilterBar() string {
 			b.WriteString(" ")
 		}
 
-		label := fmt.Sprintf("[%s]%s", filter.Section, filter.Name)
-
+		label := m.sectionLabel(filter)
 		if filter.Enabled {
 			b.WriteString(enabledStyle.Render(label))
 		} else {
@@ -772,18 +1861,87 @@ func (m Model) renderFilterBar() string {
 		}
 	}
 
+	// Only worth showing once pages come from more than one man directory
+	if m.facets != nil && len(m.facets.Sources) > 1 {
+		sources := make([]string, 0, len(m.facets.Sources))
+		for source := range m.facets.Sources {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		b.WriteString("\n  Sources: ")
+		for i, source := range sources {
+			if i > 0 {
+				b.WriteString(" · ")
+			}
+			b.WriteString(statusStyle.Render(fmt.Sprintf("%s (%d)", source, m.facets.Sources[source])))
+		}
+	}
+
 	return b.String()
 }
 
 func (m Model) renderListView() string {
 	// Calculate widths for split view
-	listWidth := int(float64(m.width) * 0.6)
-	if listWidth == 0 {
-		listWidth = 80 // default
+	listWidth := m.listWidth()
+
+	leftPanel, _ := m.renderListPanel(listWidth)
+
+	// Build right panel (preview)
+	var rightPanel strings.Builder
+	previewTitle := titleStyle.Render(" Preview ")
+	rightPanel.WriteString(previewTitle)
+	rightPanel.WriteString("\n\n")
+
+	if m.loadingPreview {
+		rightPanel.WriteString("  Loading preview...")
+	} else if m.previewContent != "" {
+		rightPanel.WriteString(m.previewPort.View())
+	} else {
+		rightPanel.WriteString("  No preview available")
+	}
+
+	// Combine left and right panels
+	leftLines := strings.Split(leftPanel, "\n")
+	rightLines := strings.Split(rightPanel.String(), "\n")
+
+	maxLines := len(leftLines)
+	if len(rightLines) > maxLines {
+		maxLines = len(rightLines)
+	}
+
+	var result strings.Builder
+	borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	for i := 0; i < maxLines; i++ {
+		// Left side
+		if i < len(leftLines) {
+			// Pad or truncate to listWidth
+			result.WriteString(fitWidth(leftLines[i], listWidth))
+		} else {
+			result.WriteString(strings.Repeat(" ", listWidth))
+		}
+
+		// Border
+		result.WriteString(borderStyle.Render(" │ "))
+
+		// Right side
+		if i < len(rightLines) {
+			result.WriteString(rightLines[i])
+		}
+
+		result.WriteString("\n")
 	}
 
-	// Build left panel (list)
+	return result.String()
+}
+
+// renderListPanel renders the left panel of the list view, returning it with
+// the index of the page on each of its rows that lists one
+func (m Model) renderListPanel(listWidth int) (string, map[int]int) {
 	var leftPanel strings.Builder
+	// Row of the panel each page in the list is on
+	pageRows := make(map[int]int)
 
 	// Title
 	title := titleStyle.Render(" LazyMan - Manual Pages ")
@@ -797,29 +1955,43 @@ func (m Model) renderListView() string {
 
 	// Error display
 	if m.err != nil {
-		leftPanel.WriteString(errorStyle.Render(fmt.Sprintf("  Error: %v\n\n", m.err)))
+		leftPanel.WriteString(renderError(m.err))
+		leftPanel.WriteString("\n\n")
+	}
+	if m.loadWarning != nil {
+		leftPanel.WriteString(warningStyle.Render(fmt.Sprintf("  Warning: %v", m.loadWarning)))
+		leftPanel.WriteString("\n")
+		leftPanel.WriteString(statusStyle.Render("  Changes to these won't be saved • esc dismiss"))
+		leftPanel.WriteString("\n\n")
 	}
 
 	// Status or no matches message
-	if len(m.filteredPages) == 0 && len(m.noMatchSuggestions) > 0 {
+	if len(m.filteredPages) == 0 && (len(m.noMatchSuggestions) > 0 || m.correctedQuery != "") {
 		noMatchStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("208")).
 			Bold(true)
 		leftPanel.WriteString(noMatchStyle.Render("  No exact matches found.\n"))
-		leftPanel.WriteString(statusStyle.Render("  Did you mean:\n\n"))
+		if m.correctedQuery != "" {
+			leftPanel.WriteString(statusStyle.Render(fmt.Sprintf("  Search for %q instead? (tab)\n", m.correctedQuery)))
+		}
+		if len(m.noMatchSuggestions) > 0 {
+			leftPanel.WriteString(statusStyle.Render("  Did you mean:\n\n"))
+		}
 
 		// Show suggestions
 		for i, page := range m.noMatchSuggestions {
 			line := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+			if page.Via != "" {
+				line += fmt.Sprintf(" (for %q)", page.Via)
+			}
 			if page.Description != "" {
 				line += " - " + page.Description
 			}
 
 			// Truncate line if too long for left panel
-			if len(line) > listWidth-6 {
-				line = line[:listWidth-9] + "..."
-			}
+			line, _ = truncateText(line, listWidth-6)
 
+			pageRows[strings.Count(leftPanel.String(), "\n")] = i
 			if i == m.cursor && m.cursor < len(m.noMatchSuggestions) {
 				leftPanel.WriteString(selectedItemStyle.Render("▸ " + line))
 			} else {
@@ -828,7 +2000,29 @@ func (m Model) renderListView() string {
 			leftPanel.WriteString("\n")
 		}
 	} else {
-		status := statusStyle.Render(fmt.Sprintf("  Showing %d man pages", len(m.filteredPages)))
+		statusText := fmt.Sprintf("  Showing %d man pages", len(m.filteredPages)-m.recentCount)
+		if m.deepSearch {
+			statusText = fmt.Sprintf("  Showing %d of %d results", len(m.filteredPages), m.deepTotal)
+			if m.loadingMore {
+				statusText += " (loading more...)"
+			}
+		}
+		if m.onlyBookmarked {
+			statusText += " (bookmarked only)"
+		}
+		if m.onlyChanged {
+			statusText += " (changed since snapshot)"
+		}
+		switch {
+		case len(m.tabs) > 0:
+			statusText += fmt.Sprintf(" · %d open (T)", len(m.tabs))
+		case len(m.session.Tabs) > 0:
+			statusText += fmt.Sprintf(" · T restores %d tabs", len(m.session.Tabs))
+		}
+		if m.status != "" {
+			statusText += " · " + m.status
+		}
+		status := statusStyle.Render(statusText)
 		leftPanel.WriteString(status)
 		leftPanel.WriteString("\n\n")
 
@@ -843,22 +2037,49 @@ func (m Model) renderListView() string {
 		}
 
 		for i := start; i < end; i++ {
+			if m.recentCount > 0 && i == 0 {
+				leftPanel.WriteString(statusStyle.Render("  Recent"))
+				leftPanel.WriteString("\n")
+			}
+			if m.recentCount > 0 && i == m.recentCount {
+				leftPanel.WriteString(statusStyle.Render("  All pages"))
+				leftPanel.WriteString("\n")
+			}
 			page := m.filteredPages[i]
 			line := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+			if page.Via != "" {
+				line += fmt.Sprintf(" (for %q)", page.Via)
+			}
 			if page.Description != "" {
 				line += " - " + page.Description
 			}
 
-			// Truncate line if too long for left panel
-			if len(line) > listWidth-6 {
-				line = line[:listWidth-9] + "..."
+			// Truncate line if too long for left panel, leaving room for
+			// the bookmark and note marks
+			marks := ""
+			if m.bookmarks.Has(page.Name, page.Section) {
+				marks += bookmarkMarkStyle.Render(" ★")
 			}
-
-			if i == m.cursor {
+			if m.notes.Has(page.Name, page.Section) {
+				marks += noteMarkStyle.Render(" ✎")
+			}
+			line, visible := truncateText(line, listWidth-10)
+
+			pageRows[strings.Count(leftPanel.String(), "\n")] = i
+			match, filtered := m.fuzzyMatches[fmt.Sprintf("%s(%s)", page.Name, page.Section)]
+			switch {
+			case filtered && i == m.cursor:
+				positions := rowMatchPositions(page, match, visible, len("▸ "))
+				leftPanel.WriteString("  " + highlightPositions("▸ "+line, positions, selectedItemStyle.UnsetPaddingLeft(), fuzzyMatchStyle))
+			case filtered:
+				positions := rowMatchPositions(page, match, visible, 0)
+				leftPanel.WriteString("    " + highlightPositions(line, positions, lipgloss.NewStyle(), fuzzyMatchStyle))
+			case i == m.cursor:
 				leftPanel.WriteString(selectedItemStyle.Render("▸ " + line))
-			} else {
+			default:
 				leftPanel.WriteString(itemStyle.Render(line))
 			}
+			leftPanel.WriteString(marks)
 			leftPanel.WriteString("\n")
 		}
 	}
@@ -866,86 +2087,38 @@ func (m Model) renderListView() string {
 	// Help
 	leftPanel.WriteString("\n")
 	help := helpStyle.Render(
-		"↑/k up • ↓/j down • enter view • / search • 1-9 toggle filter • r refresh • q quit",
+		"↑/k up • ↓/j down • enter view • t new tab • T tabs • c compare with open page • / search • 1-9 toggle filter • m bookmark • B bookmarks • * bookmarked only • U changed only • H history • r refresh • q quit",
 	)
 	leftPanel.WriteString(help)
 
-	// Build right panel (preview)
-	var rightPanel strings.Builder
-	previewTitle := titleStyle.Render(" Preview ")
-	rightPanel.WriteString(previewTitle)
-	rightPanel.WriteString("\n\n")
-
-	if m.loadingPreview {
-		rightPanel.WriteString("  Loading preview...")
-	} else if m.previewContent != "" {
-		rightPanel.WriteString(m.previewPort.View())
-	} else {
-		rightPanel.WriteString("  No preview available")
-	}
-
-	// Combine left and right panels
-	leftLines := strings.Split(leftPanel.String(), "\n")
-	rightLines := strings.Split(rightPanel.String(), "\n")
-
-	maxLines := len(leftLines)
-	if len(rightLines) > maxLines {
-		maxLines = len(rightLines)
-	}
-
-	var result strings.Builder
-	borderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-
-	for i := 0; i < maxLines; i++ {
-		// Left side
-		if i < len(leftLines) {
-			line := leftLines[i]
-			// Pad or truncate to listWidth
-			visualLen := lipgloss.Width(line)
-			if visualLen < listWidth {
-				line += strings.Repeat(" ", listWidth-visualLen)
-			} else if visualLen > listWidth {
-				line = line[:listWidth]
-			}
-			result.WriteString(line)
-		} else {
-			result.WriteString(strings.Repeat(" ", listWidth))
-		}
-
-		// Border
-		result.WriteString(borderStyle.Render(" │ "))
-
-		// Right side
-		if i < len(rightLines) {
-			result.WriteString(rightLines[i])
-		}
-
-		result.WriteString("\n")
-	}
-
-	return result.String()
+	return leftPanel.String(), pageRows
 }
 
 func (m Model) renderDetailView() string {
 	var b strings.Builder
 
-	// Title
-	if len(m.filteredPages) > 0 && m.cursor < len(m.filteredPages) {
-		page := m.filteredPages[m.cursor]
-		title := titleStyle.Render(fmt.Sprintf(" %s(%s) ", page.Name, page.Section))
-		b.WriteString(title)
+	// Title, or the tab bar once there are several tabs
+	if page := m.currentPage; page.Name != "" {
+		if len(m.tabs) > 1 {
+			b.WriteString(m.renderTabBar(m.width * 2 / 3))
+		} else {
+			b.WriteString(titleStyle.Render(fmt.Sprintf(" %s(%s) ", page.Name, page.Section)))
+		}
 
 		// Show search info if active
 		if m.searchQuery != "" {
-			searchInfo := statusStyle.Render(fmt.Sprintf("  [Search: %s - Match %d/%d]",
-				m.searchQuery, m.currentMatch+1, len(m.searchMatches)))
-			b.WriteString(searchInfo)
+			b.WriteString(statusStyle.Render("  " + m.matchIndicator()))
+		}
+		if m.status != "" {
+			b.WriteString(statusStyle.Render("  " + m.status))
 		}
 		b.WriteString("\n\n")
 	}
 
-	// Content viewport - with highlighting if search is active
-	if m.searchQuery != "" {
+	// Content viewport - with highlighting if search or a selection is active
+	if m.showKeys && m.mode == detailView {
+		b.WriteString(m.renderDetailKeys())
+	} else if m.searchQuery != "" || m.mode == visualView {
 		b.WriteString(m.renderHighlightedContent())
 	} else {
 		b.WriteString(m.viewport.View())
@@ -954,10 +2127,17 @@ func (m Model) renderDetailView() string {
 
 	// Help
 	var helpText string
-	if m.searchQuery != "" {
-		helpText = "↑/k up • ↓/j down • n next match • N prev match • / search • q/esc back"
+	if m.widget != nil && m.mode == detailView {
+		helpText = "↑/k ↓/j scroll • / search • enter insert option on screen • E examples • v/V select • q/esc cancel"
+	} else if m.mode == visualView {
+		helpText = "hjkl move • w/b word • 0/^/$ line • u/d half page • o other end • v/V by character/line • y copy • esc cancel"
+	} else if m.searchQuery != "" {
+		helpText = "↑/k up • ↓/j down • n next match • N prev match • / search • & filter lines • q/esc back • ? all keys"
 	} else {
-		helpText = "↑/k up • ↓/j down • g top • G bottom • u/d half page • / search • q/esc back"
+		helpText = "↑/k ↓/j scroll • / search • v/V select • y copy • E examples • R related • B bookmarks • q/esc list • ? all keys"
+	}
+	if m.showKeys && m.mode == detailView {
+		helpText = "any key to close"
 	}
 	help := helpStyle.Render(helpText)
 	b.WriteString(help)
@@ -965,53 +2145,91 @@ func (m Model) renderDetailView() string {
 	return b.String()
 }
 
-// renderHighlightedContent renders the viewport content with search terms highlighted
-func (m Model) renderHighlightedContent() string {
-	lines := strings.Split(m.currentContent, "\n")
+// detailKeys lists every key of the detail view, shown with ?
+var detailKeys = [][2]string{
+	{"↑/k ↓/j", "scroll a line"},
+	{"u/d", "half page up/down"},
+	{"g/G", "top/bottom"},
+	{"/", "search the page"},
+	{"n/N", "next/previous match"},
+	{"&", "filter lines"},
+	{"v/V", "select characters/lines"},
+	{"y", "copy synopsis, option, example or line"},
+	{"E", "examples"},
+	{"R", "related pages"},
+	{"D", "changes since the last snapshot"},
+	{"m", "bookmark here"},
+	{"B", "bookmarks"},
+	{"a", "add a note"},
+	{"e", "edit notes"},
+	{"o/O", "expand the note on screen/all notes"},
+	{"[/]", "previous/next note"},
+	{"tab/1-9", "switch tab"},
+	{"x", "close tab"},
+	{"c", "compare tabs"},
+	{"</>", "back/forward"},
+	{"q/esc", "back to the list"},
+}
 
-	// Define highlight style
-	highlightStyle := lipgloss.NewStyle().
-		Background(lipgloss.Color("226")).
-		Foreground(lipgloss.Color("0")).
-		Bold(true)
+// renderDetailKeys lays detailKeys out in as many columns as the page's
+// height needs
+func (m Model) renderDetailKeys() string {
+	keyWidth, entryWidth := 0, 0
+	for _, key := range detailKeys {
+		keyWidth = max(keyWidth, lipgloss.Width(key[0]))
+	}
+	entries := make([]string, len(detailKeys))
+	for i, key := range detailKeys {
+		padding := strings.Repeat(" ", keyWidth-lipgloss.Width(key[0]))
+		entries[i] = fmt.Sprintf("  %s%s  %s", key[0], padding, key[1])
+		entryWidth = max(entryWidth, lipgloss.Width(entries[i]))
+	}
+
+	rows := max(m.viewport.Height, 1)
+	lines := make([]string, rows)
+	for i, entry := range entries {
+		lines[i%rows] += entry + strings.Repeat(" ", entryWidth+2-lipgloss.Width(entry))
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(strings.Join(lines, "\n"))
+}
+
+// renderHighlightedContent renders the viewport content with every search
+// match highlighted and the current one emphasized, or with the visual
+// selection
+func (m Model) renderHighlightedContent() string {
+	lines := m.currentLines
 
 	// Get the visible range from viewport
 	yOffset := m.viewport.YOffset
 	visibleHeight := m.viewport.Height
 
 	var result strings.Builder
-	searchLower := strings.ToLower(m.searchQuery)
-
-	for i := yOffset; i < yOffset+visibleHeight && i < len(lines); i++ {
-		line := lines[i]
-		lineLower := strings.ToLower(line)
-
-		// Find and highlight all occurrences in this line
-		if strings.Contains(lineLower, searchLower) {
-			lastIndex := 0
-			for {
-				index := strings.Index(lineLower[lastIndex:], searchLower)
-				if index == -1 {
-					// No more matches, append rest of line
-					result.WriteString(line[lastIndex:])
-					break
-				}
 
-				// Append text before match
-				actualIndex := lastIndex + index
-				result.WriteString(line[lastIndex:actualIndex])
-
-				// Append highlighted match
-				matchEnd := actualIndex + len(m.searchQuery)
-				result.WriteString(highlightStyle.Render(line[actualIndex:matchEnd]))
-
-				lastIndex = matchEnd
+	for row := yOffset; row < yOffset+visibleHeight && row < len(m.displayMap); row++ {
+		if i := m.displayMap[row]; i >= 0 {
+			var ranges [][2]int
+			var styles []lipgloss.Style
+			if m.mode == visualView {
+				ranges, styles = m.selectionRanges(i, lines[i].Text)
+			} else {
+				matches, first := lineMatches(m.searchMatches, i)
+				ranges = make([][2]int, len(matches))
+				styles = make([]lipgloss.Style, len(matches))
+				for j, match := range matches {
+					ranges[j] = [2]int{match.start, match.end}
+					styles[j] = findMatchStyle
+					if first+j == m.currentMatch {
+						styles[j] = findCurrentStyle
+					}
+				}
 			}
+			result.WriteString(m.noteGutter(i))
+			result.WriteString(renderHighlightedLine(lines[i], ranges, styles))
 		} else {
-			result.WriteString(line)
+			result.WriteString(m.displayRows[row])
 		}
 
-		if i < yOffset+visibleHeight-1 && i < len(lines)-1 {
+		if row < yOffset+visibleHeight-1 && row < len(m.displayMap)-1 {
 			result.WriteString("\n")
 		}
 	}
@@ -1030,6 +2248,23 @@ func (m Model) renderSearchView() string {
 	b.WriteString(m.searchInput.View())
 	b.WriteString("\n")
 
+	if m.deepSearch {
+		switch {
+		case m.err != nil:
+			b.WriteString(renderError(m.err))
+			b.WriteString("\n")
+		case m.searching:
+			b.WriteString(statusStyle.Render("  Searching..."))
+			b.WriteString("\n")
+		case m.initialQuery != "" && m.initialQuery == m.searchInput.Value():
+			b.WriteString(statusStyle.Render(fmt.Sprintf("  Found %d matches", m.deepTotal)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("type to search the index • enter confirm • esc cancel • see lazyman -S --help for query syntax"))
+		return b.String()
+	}
+
 	// Show real-time results count
 	if m.searchInput.Value() != "" {
 		resultInfo := statusStyle.Render(fmt.Sprintf("  Found %d matches", len(m.filteredPages)))
@@ -1045,6 +2280,9 @@ func (m Model) renderSearchView() string {
 		for i := 0; i < maxResults; i++ {
 			page := m.filteredPages[i]
 			line := fmt.Sprintf("%s(%s)", page.Name, page.Section)
+			if page.Via != "" {
+				line += fmt.Sprintf(" (for %q)", page.Via)
+			}
 			if page.Description != "" {
 				line += " - " + page.Description
 			}
@@ -1067,6 +2305,15 @@ func (m Model) renderSearchView() string {
 			b.WriteString(more)
 			b.WriteString("\n")
 		}
+
+		if len(m.filteredPages) == 0 && len(m.noMatchSuggestions) > 0 {
+			names := make([]string, len(m.noMatchSuggestions))
+			for i, page := range m.noMatchSuggestions {
+				names[i] = fmt.Sprintf("%s(%s)", page.Name, page.Section)
+			}
+			b.WriteString(statusStyle.Render("  Did you mean: " + strings.Join(names, ", ")))
+			b.WriteString("\n")
+		}
 	}
 
 	b.WriteString("\n")
@@ -1076,19 +2323,15 @@ func (m Model) renderSearchView() string {
 	return b.String()
 }
 
-func (m Model) renderDetailSearchView() string {
-	var b strings.Builder
-
-	title := titleStyle.Render(" Search in Document ")
-	b.WriteString(title)
-	b.WriteString("\n\n")
-
-	b.WriteString("  ")
-	b.WriteString(m.detailSearchInput.View())
-	b.WriteString("\n\n")
-
-	help := helpStyle.Render("enter search • esc cancel")
-	b.WriteString(help)
-
-	return b.String()
+// renderError formats an error for display, showing where a query failed to parse
+func renderError(err error) string {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		lines := strings.Split(queryErr.Pretty(), "\n")
+		for i, line := range lines {
+			lines[i] = "  " + line
+		}
+		return errorStyle.Render(strings.Join(lines, "\n"))
+	}
+	return errorStyle.Render(fmt.Sprintf("  Error: %v", err))
 }
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	visualStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("238"))
+
+	visualCursorStyle = lipgloss.NewStyle().
+				Reverse(true)
+)
+
+// textPos is a byte in the open page
+type textPos struct {
+	line, col int
+}
+
+func (p textPos) before(q textPos) bool {
+	return p.line < q.line || p.line == q.line && p.col < q.col
+}
+
+// visualSelection is the detail view's vim-style visual selection, from
+// where it was started to the cursor
+type visualSelection struct {
+	lineWise bool
+	anchor   textPos
+	cursor   textPos
+}
+
+// bounds returns the ends of the selection in page order
+func (v visualSelection) bounds() (textPos, textPos) {
+	if v.cursor.before(v.anchor) {
+		return v.cursor, v.anchor
+	}
+	return v.anchor, v.cursor
+}
+
+type clipboardMsg struct {
+	what string // what was copied, e.g. "SYNOPSIS"
+	err  error
+}
+
+func copyText(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		return clipboardMsg{what: what, err: copyToClipboard(text)}
+	}
+}
+
+// startVisual starts selecting at the first line of text on screen, by
+// character or by line
+func (m *Model) startVisual(lineWise bool) {
+	lines := strings.Split(m.currentContent, "\n")
+	line := m.firstTextLine()
+	if line >= len(lines) {
+		return
+	}
+	pos := textPos{line: line, col: lineIndent(lines[line])}
+	m.visual = visualSelection{lineWise: lineWise, anchor: pos, cursor: pos}
+	m.mode = visualView
+}
+
+// moveVisualCursor puts the cursor at line and col, kept within the page and
+// on screen
+func (m *Model) moveVisualCursor(line, col int) {
+	lines := strings.Split(m.currentContent, "\n")
+	line = max(min(line, len(lines)-1), 0)
+	col = max(min(col, len(lines[line])-1), 0)
+	// Land on the start of a character
+	for col > 0 && !utf8.RuneStart(lines[line][col]) {
+		col--
+	}
+	m.visual.cursor = textPos{line: line, col: col}
+
+	row := line
+	if line < len(m.contentRow) {
+		row = m.contentRow[line]
+	}
+	if row < m.viewport.YOffset {
+		m.viewport.SetYOffset(row)
+	} else if row >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(row - m.viewport.Height + 1)
+	}
+}
+
+// selectedText returns the selected text. Whole lines are copied without the
+// indentation they share.
+func (m Model) selectedText() string {
+	lines := strings.Split(m.currentContent, "\n")
+	start, end := m.visual.bounds()
+	if m.visual.lineWise {
+		return dedentLines(lines[start.line : end.line+1])
+	}
+
+	// The cursor's character is included
+	endCol := end.col
+	if endCol < len(lines[end.line]) {
+		_, size := utf8.DecodeRuneInString(lines[end.line][endCol:])
+		endCol += size
+	}
+	if start.line == end.line {
+		return lines[start.line][min(start.col, endCol):endCol]
+	}
+	selected := []string{lines[start.line][min(start.col, len(lines[start.line])):]}
+	selected = append(selected, lines[start.line+1:end.line]...)
+	selected = append(selected, lines[end.line][:endCol])
+	return strings.Join(selected, "\n")
+}
+
+// selectionRanges returns the byte ranges of a line to draw as selected and
+// as the cursor, with their styles
+func (m Model) selectionRanges(line int, text string) ([][2]int, []lipgloss.Style) {
+	start, end := m.visual.bounds()
+	if line < start.line || line > end.line {
+		return nil, nil
+	}
+	from, to := 0, len(text)
+	if !m.visual.lineWise {
+		if line == start.line {
+			from = min(start.col, len(text))
+		}
+		if line == end.line {
+			to = min(end.col, len(text))
+			if to < len(text) {
+				_, size := utf8.DecodeRuneInString(text[to:])
+				to += size
+			}
+		}
+	}
+
+	var ranges [][2]int
+	var styles []lipgloss.Style
+	cursor := m.visual.cursor
+	if cursor.line != line || cursor.col >= len(text) {
+		return [][2]int{{from, to}}, []lipgloss.Style{visualStyle}
+	}
+	_, size := utf8.DecodeRuneInString(text[cursor.col:])
+	if from < cursor.col {
+		ranges = append(ranges, [2]int{from, cursor.col})
+		styles = append(styles, visualStyle)
+	}
+	ranges = append(ranges, [2]int{cursor.col, cursor.col + size})
+	styles = append(styles, visualCursorStyle)
+	if cursor.col+size < to {
+		ranges = append(ranges, [2]int{cursor.col + size, to})
+		styles = append(styles, visualStyle)
+	}
+	return ranges, styles
+}
+
+// wordStart returns the start of the next word after col in text, or of the
+// previous one when step is negative
+func wordStart(text string, col, step int) int {
+	isSpace := func(i int) bool { return text[i] == ' ' || text[i] == '\t' }
+	if step > 0 {
+		i := col
+		for i < len(text) && !isSpace(i) {
+			i++
+		}
+		for i < len(text) && isSpace(i) {
+			i++
+		}
+		return min(i, max(len(text)-1, 0))
+	}
+	i := min(col, len(text)) - 1
+	for i > 0 && isSpace(i) {
+		i--
+	}
+	for i > 0 && !isSpace(i-1) {
+		i--
+	}
+	return max(i, 0)
+}
+
+// updateVisualView handles keys while text is selected in the detail view
+func (m Model) updateVisualView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	lines := strings.Split(m.currentContent, "\n")
+	cursor := m.visual.cursor
+	text := lines[cursor.line]
+
+	switch msg.String() {
+	case "ctrl+c", "esc", "q":
+		m.mode = detailView
+
+	case "v":
+		if !m.visual.lineWise {
+			m.mode = detailView
+		}
+		m.visual.lineWise = false
+
+	case "V":
+		if m.visual.lineWise {
+			m.mode = detailView
+		}
+		m.visual.lineWise = true
+
+	case "up", "k":
+		m.moveVisualCursor(cursor.line-1, cursor.col)
+
+	case "down", "j":
+		m.moveVisualCursor(cursor.line+1, cursor.col)
+
+	case "left", "h":
+		_, size := utf8.DecodeLastRuneInString(text[:min(cursor.col, len(text))])
+		m.moveVisualCursor(cursor.line, cursor.col-max(size, 1))
+
+	case "right", "l":
+		_, size := utf8.DecodeRuneInString(text[min(cursor.col, len(text)):])
+		m.moveVisualCursor(cursor.line, cursor.col+max(size, 1))
+
+	case "w":
+		m.moveVisualCursor(cursor.line, wordStart(text, cursor.col, 1))
+
+	case "b":
+		m.moveVisualCursor(cursor.line, wordStart(text, cursor.col, -1))
+
+	case "0":
+		m.moveVisualCursor(cursor.line, 0)
+
+	case "^":
+		m.moveVisualCursor(cursor.line, lineIndent(text))
+
+	case "$":
+		m.moveVisualCursor(cursor.line, len(text))
+
+	case "u", "ctrl+u":
+		m.moveVisualCursor(cursor.line-m.viewport.Height/2, cursor.col)
+
+	case "d", "ctrl+d":
+		m.moveVisualCursor(cursor.line+m.viewport.Height/2, cursor.col)
+
+	case "g":
+		m.moveVisualCursor(0, 0)
+
+	case "G":
+		m.moveVisualCursor(len(lines)-1, 0)
+
+	case "o":
+		// Move to the other end of the selection
+		m.visual.anchor, m.visual.cursor = m.visual.cursor, m.visual.anchor
+		m.moveVisualCursor(m.visual.cursor.line, m.visual.cursor.col)
+
+	case "y", "enter":
+		start, end := m.visual.bounds()
+		what := "selection"
+		if m.visual.lineWise {
+			what = fmt.Sprintf("%d lines", end.line-start.line+1)
+		}
+		m.mode = detailView
+		return m, copyText(m.selectedText(), what)
+	}
+
+	return m, nil
+}
+
+// quickCopy copies part of the page picked by the key pressed after y: the
+// SYNOPSIS, the option entry or code example on screen, or the first line
+// on screen
+func (m *Model) quickCopy(key string) tea.Cmd {
+	lines := strings.Split(m.currentContent, "\n")
+	top := m.firstTextLine()
+	bottom := m.topLine() + m.viewport.Height
+
+	var start, end int
+	var what string
+	switch key {
+	case "s":
+		start, end = synopsisLines(lines)
+		what = "SYNOPSIS"
+	case "o":
+		start, end = optionEntryAt(lines, top, bottom)
+		if start < end {
+			what = strings.Join(optionFlags(strings.TrimSpace(lines[start])), ", ")
+		}
+	case "e":
+		start, end = codeBlockAt(lines, top, bottom)
+		what = "example"
+	case "y":
+		start, end = top, min(top+1, len(lines))
+		what = "line"
+	default:
+		return nil
+	}
+
+	if start >= end {
+		switch key {
+		case "s":
+			m.status = "This page has no SYNOPSIS"
+		case "o":
+			m.status = "No option entry on screen"
+		case "e":
+			m.status = "No example on screen"
+		}
+		return nil
+	}
+	return copyText(dedentLines(lines[start:end]), what)
+}
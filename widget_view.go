@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// widgetHeight is the most rows lazyman takes below the prompt when run by a
+// shell widget
+const widgetHeight = 20
+
+// updateWidgetKeys handles the detail view keys that return to the shell when
+// run by a shell widget, reporting whether the key was one of them
+func (m *Model) updateWidgetKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		m.quitting = true
+		return true, tea.Quit
+
+	case "esc":
+		if m.searchQuery != "" {
+			// Clears the search as usual
+			return false, nil
+		}
+		m.quitting = true
+		return true, tea.Quit
+
+	case "enter":
+		// Insert the flag of the option entry on screen
+		lines := strings.Split(m.currentContent, "\n")
+		start, end := optionEntryAt(lines, m.firstTextLine(), m.topLine()+m.viewport.Height)
+		if start == end {
+			m.status = "No option entry on screen"
+			return true, nil
+		}
+		flags := optionFlags(strings.TrimSpace(lines[start]))
+		flag := flags[0]
+		// Keep the form being typed when the entry has several
+		for _, f := range flags {
+			if m.widget.flag != "" && strings.HasPrefix(f, m.widget.flag) {
+				flag = f
+				break
+			}
+		}
+		m.picked, m.pickedPoint = m.widget.insertFlag(flag)
+		m.quitting = true
+		return true, tea.Quit
+	}
+	return false, nil
+}